@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/syllabix/logger/internal/registry"
+	"go.uber.org/zap/zapcore"
+)
+
+// PackageLevel is the log level currently applied to all logger
+// instances created in a package
+type PackageLevel struct {
+	Package string        `json:"package"`
+	Level   zapcore.Level `json:"level"`
+	// Explicit is true when the level was set on the package itself
+	Explicit bool `json:"explicit"`
+	// From is the package or prefix the level was inherited from,
+	// empty when the default level applies
+	From string `json:"from,omitempty"`
+}
+
+// LevelReport is the payload returned by the admin handler, listing the
+// default log level, all explicitly set levels and the effective level
+// of every registered package
+type LevelReport struct {
+	Default   zapcore.Level            `json:"default"`
+	Overrides map[string]zapcore.Level `json:"overrides"`
+	Packages  []PackageLevel           `json:"packages"`
+}
+
+// LevelChange is the payload accepted by the admin handler. Exactly one of
+// Package, Glob or Default should be set to select what Level is applied to.
+// Level is required when changing levels, and ignored when clearing them
+type LevelChange struct {
+	Level *zapcore.Level `json:"level,omitempty"`
+	// Package is the name of a registered package, or a prefix of one
+	Package string `json:"package,omitempty"`
+	// Glob is a path.Match pattern applied to all registered packages
+	Glob string `json:"glob,omitempty"`
+	// Default updates the level applied to newly registered packages
+	Default bool `json:"default,omitempty"`
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+// AdminHandler returns an http.Handler that can be used to inspect and change
+// log levels at runtime. A GET request responds with a LevelReport, while a PUT
+// request with a LevelChange body updates the selected package(s) or the default
+// level and responds with the resulting LevelReport. A DELETE request with a
+// LevelChange body naming a package clears the level set on it
+func AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, levelReport())
+		case http.MethodPut:
+			var change LevelChange
+			if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+				writeJSON(w, http.StatusBadRequest, adminError{err.Error()})
+				return
+			}
+			if err := applyLevelChange(change); err != nil {
+				writeJSON(w, statusFor(err), adminError{err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, levelReport())
+		case http.MethodDelete:
+			var change LevelChange
+			if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+				writeJSON(w, http.StatusBadRequest, adminError{err.Error()})
+				return
+			}
+			if err := registry.Clear(registry.Package(change.Package), registry.SourceAdmin); err != nil {
+				writeJSON(w, statusFor(err), adminError{err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, levelReport())
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			writeJSON(w, http.StatusMethodNotAllowed, adminError{"method not allowed"})
+		}
+	})
+}
+
+var (
+	errAmbiguousChange = errors.New("exactly one of package, glob or default must be provided")
+	errMissingLevel    = errors.New("a level must be provided")
+)
+
+func applyLevelChange(change LevelChange) error {
+	targets := 0
+	for _, set := range []bool{len(change.Package) > 0, len(change.Glob) > 0, change.Default} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return errAmbiguousChange
+	}
+	if change.Level == nil {
+		return errMissingLevel
+	}
+
+	switch {
+	case change.Default:
+		registry.SetDefaultLevel(*change.Level, registry.SourceAdmin)
+		return nil
+	case len(change.Glob) > 0:
+		_, err := registry.SetMatching(change.Glob, *change.Level, registry.SourceAdmin)
+		return err
+	default:
+		return registry.Set(registry.Package(change.Package), *change.Level, registry.SourceAdmin)
+	}
+}
+
+func levelReport() LevelReport {
+	overrides := registry.Overrides()
+	report := LevelReport{
+		Default:   registry.DefaultLevel(),
+		Overrides: make(map[string]zapcore.Level, len(overrides)),
+		Packages:  GetPackageLevels(),
+	}
+
+	for pkg, lvl := range overrides {
+		report.Overrides[string(pkg)] = lvl
+	}
+	return report
+}
+
+func statusFor(err error) int {
+	switch err {
+	case registry.ErrPkgNotRegistered, registry.ErrNoOverride:
+		return http.StatusNotFound
+	case errAmbiguousChange, errMissingLevel, path.ErrBadPattern:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAdminHandler(t *testing.T) {
+	before()
+	defer after()
+	defer registry.Reset()
+
+	registry.Get("admin/svc")
+	registry.Get("admin/svc/store")
+	registry.Get("admin/svc/http")
+	registry.Get("admin/jobs")
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		assertion  func(t *testing.T, report LevelReport)
+	}{
+		{
+			name:       "list packages",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			assertion: func(t *testing.T, report LevelReport) {
+				assert.Equal(t, zap.InfoLevel, levelOf(report, "admin/svc"))
+			},
+		},
+		{
+			name:       "set single package",
+			method:     http.MethodPut,
+			body:       `{"package":"admin/svc","level":"debug"}`,
+			wantStatus: http.StatusOK,
+			assertion: func(t *testing.T, report LevelReport) {
+				assert.Equal(t, zap.DebugLevel, levelOf(report, "admin/svc"))
+				assert.Equal(t, zap.DebugLevel, levelOf(report, "admin/svc/store"))
+			},
+		},
+		{
+			name:       "set prefix",
+			method:     http.MethodPut,
+			body:       `{"package":"admin","level":"warn"}`,
+			wantStatus: http.StatusOK,
+			assertion: func(t *testing.T, report LevelReport) {
+				assert.Equal(t, zap.DebugLevel, levelOf(report, "admin/svc"))
+				assert.Equal(t, zap.DebugLevel, levelOf(report, "admin/svc/store"))
+				assert.Equal(t, zap.WarnLevel, levelOf(report, "admin/jobs"))
+				assert.Equal(t, zap.WarnLevel, report.Overrides["admin"])
+			},
+		},
+		{
+			name:       "set glob",
+			method:     http.MethodPut,
+			body:       `{"glob":"admin/svc/*","level":"error"}`,
+			wantStatus: http.StatusOK,
+			assertion: func(t *testing.T, report LevelReport) {
+				assert.Equal(t, zap.ErrorLevel, levelOf(report, "admin/svc/store"))
+				assert.Equal(t, zap.ErrorLevel, levelOf(report, "admin/svc/http"))
+			},
+		},
+		{
+			name:       "set default",
+			method:     http.MethodPut,
+			body:       `{"default":true,"level":"warn"}`,
+			wantStatus: http.StatusOK,
+			assertion: func(t *testing.T, report LevelReport) {
+				assert.Equal(t, zapcore.WarnLevel, report.Default)
+			},
+		},
+		{
+			name:       "clear package",
+			method:     http.MethodDelete,
+			body:       `{"package":"admin/svc"}`,
+			wantStatus: http.StatusOK,
+			assertion: func(t *testing.T, report LevelReport) {
+				assert.Equal(t, zap.WarnLevel, levelOf(report, "admin/svc"))
+			},
+		},
+		{
+			name:       "clear without override",
+			method:     http.MethodDelete,
+			body:       `{"package":"admin/svc"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown package",
+			method:     http.MethodPut,
+			body:       `{"package":"admin/nope","level":"debug"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "ambiguous target",
+			method:     http.MethodPut,
+			body:       `{"package":"admin/svc","glob":"admin/*","level":"debug"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing level",
+			method:     http.MethodPut,
+			body:       `{"package":"admin/svc"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "null level",
+			method:     http.MethodPut,
+			body:       `{"default":true,"level":null}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid level",
+			method:     http.MethodPut,
+			body:       `{"package":"admin/svc","level":"loud"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported method",
+			method:     http.MethodPost,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/log/levels", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			AdminHandler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.assertion != nil {
+				var report LevelReport
+				err := json.NewDecoder(rec.Body).Decode(&report)
+				assert.NoError(t, err)
+				tt.assertion(t, report)
+			}
+		})
+	}
+}
+
+func levelOf(report LevelReport, pkg string) zapcore.Level {
+	for _, p := range report.Packages {
+		if p.Package == pkg {
+			return p.Level
+		}
+	}
+	// not a valid zap level, so it never equals an expectation
+	return zapcore.Level(127)
+}
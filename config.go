@@ -4,7 +4,10 @@ import (
 	"io"
 	"os"
 
+	"github.com/syllabix/logger/console"
+	"github.com/syllabix/logger/encode"
 	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/json"
 
 	"github.com/syllabix/logger/mode"
 	"go.uber.org/zap"
@@ -18,10 +21,41 @@ type Config struct {
 	mode mode.Kind
 	// console/local sink
 	csink io.Writer
+	// cformat encodes entries written to the console sink
+	cformat Encoding
+	// cescape escapes user supplied values in console output
+	cescape bool
+	// cnamespaces is how the console renders namespaces, and cshowroot
+	// whether it renders the @fields namespace
+	cnamespaces console.NamespaceStyle
+	cshowroot   bool
+	// cpretty writes stacktraces and error chains below console entries
+	cpretty bool
+	// ccolors is when console output is colored, and ctheme how
+	ccolors encode.ColorMode
+	ctheme  *encode.Theme
 	// json sink
 	jsink   io.Writer
 	appname string
 	level   zapcore.Level
+	keys    EncoderKeys
+	// levelSet is set when the Level option was given since the last call
+	// to Configure, so other calls leave the default level alone
+	levelSet bool
+	// schema is the layout of json output
+	schema json.Schema
+	// gcpProject is the Google Cloud project traces belong to
+	gcpProject string
+	// package levels to apply on the next call to Configure
+	pkglevels map[string]zapcore.Level
+	// sampling policies per level, and their package overrides
+	sampling    map[zapcore.Level]SamplingPolicy
+	pkgsampling map[registry.Package]map[zapcore.Level]SamplingPolicy
+	onSampled   func(zapcore.Entry, uint64)
+	// named sinks written to in addition to the console and json sinks
+	sinks map[string]sinkConfig
+	// traceExtractor finds the trace of a context
+	traceExtractor TraceExtractor
 }
 
 // sane defaults
@@ -33,6 +67,37 @@ var global = &Config{
 	level:   zap.InfoLevel,
 }
 
+// EncoderKeys are the keys used for the entry fields written by the console
+// and json encoders. Keys that are left empty keep the encoder default
+type EncoderKeys struct {
+	Message    string `json:"message" yaml:"message"`
+	Level      string `json:"level" yaml:"level"`
+	Time       string `json:"time" yaml:"time"`
+	Name       string `json:"name" yaml:"name"`
+	Caller     string `json:"caller" yaml:"caller"`
+	Stacktrace string `json:"stacktrace" yaml:"stacktrace"`
+}
+
+func (k EncoderKeys) isZero() bool {
+	return k == EncoderKeys{}
+}
+
+// apply returns a copy of cfg with the non empty keys applied
+func (k EncoderKeys) apply(cfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	override := func(dst *string, key string) {
+		if len(key) > 0 {
+			*dst = key
+		}
+	}
+	override(&cfg.MessageKey, k.Message)
+	override(&cfg.LevelKey, k.Level)
+	override(&cfg.TimeKey, k.Time)
+	override(&cfg.NameKey, k.Name)
+	override(&cfg.CallerKey, k.Caller)
+	override(&cfg.StacktraceKey, k.Stacktrace)
+	return cfg
+}
+
 // An Option can be used to apply a value to a setting
 // on the global config
 type Option func(config *Config)
@@ -53,6 +118,71 @@ func ConsoleWriter(w io.Writer) Option {
 	}
 }
 
+// ConsoleFormat sets the encoding of the entries written by the console
+// writer, for example LogfmtEncoding. A nil encoding restores the default
+// console format
+func ConsoleFormat(enc Encoding) Option {
+	return func(config *Config) {
+		config.cformat = enc
+	}
+}
+
+// ConsoleEscaping turns escaping of messages and field values written by the
+// console encoder on or off. When on, values are quoted when they contain
+// spaces, equal signs or quotes, control characters are escaped and ANSI
+// escape sequences are stripped, so a value can not break an entry over
+// several lines or recolor the terminal
+func ConsoleEscaping(enabled bool) Option {
+	return func(config *Config) {
+		config.cescape = enabled
+	}
+}
+
+// ConsoleNamespaces sets how the console encoder renders fields added in a
+// namespace, such as with zap.Namespace. By default keys are prefixed with
+// their namespaces, as in http.status=200
+func ConsoleNamespaces(style console.NamespaceStyle) Option {
+	return func(config *Config) {
+		config.cnamespaces = style
+	}
+}
+
+// ConsoleRootNamespace sets whether the console encoder renders the @fields
+// namespace that all context fields are added in, which it hides by default
+func ConsoleRootNamespace(show bool) Option {
+	return func(config *Config) {
+		config.cshowroot = show
+	}
+}
+
+// ConsolePretty turns pretty output of stacktraces and errors on or off in
+// development mode. When on, the stacktrace of an entry is written below it,
+// one frame per line with the frames of the main module highlighted, and
+// error fields wrapping other errors are written below it as well, followed
+// by the chain of errors they wrap
+func ConsolePretty(enabled bool) Option {
+	return func(config *Config) {
+		config.cpretty = enabled
+	}
+}
+
+// ConsoleColors sets when console output is colored. By default it is colored
+// when the console writer is a terminal, unless the NO_COLOR environment
+// variable is set. Setting FORCE_COLOR colors it regardless of the writer
+func ConsoleColors(when encode.ColorMode) Option {
+	return func(config *Config) {
+		config.ccolors = when
+	}
+}
+
+// ConsoleTheme sets the styles colored console output is written in, which
+// default to those of encode.DefaultTheme
+func ConsoleTheme(theme encode.Theme) Option {
+	return func(config *Config) {
+		config.ctheme = &theme
+	}
+}
+
 // JSONWriter sets the writer that will receive json formatted output
 // from a logger
 func JSONWriter(w io.Writer) Option {
@@ -73,15 +203,68 @@ func Mode(m mode.Kind) Option {
 func Level(lvl zapcore.Level) Option {
 	return func(config *Config) {
 		config.level = lvl
+		config.levelSet = true
+	}
+}
+
+// Keys sets the keys used for entry fields such as the message and caller
+// by both the console and json encoders
+func Keys(keys EncoderKeys) Option {
+	return func(config *Config) {
+		config.keys = keys
+	}
+}
+
+// JSONSchema sets the layout of entries written by the json writer and by
+// sinks using JSONEncoding. The default is json.Legacy, the original layout
+// with @message and @source_host keys. The cloud schemas json.GCP and
+// json.CloudWatch write the structured json that Google Cloud Logging and AWS
+// CloudWatch Logs parse from stdout
+func JSONSchema(schema json.Schema) Option {
+	return func(config *Config) {
+		config.schema = schema
+	}
+}
+
+// GCPProject sets the Google Cloud project that traces belong to, so the
+// json.GCP schema writes traces in the form Cloud Logging links to Cloud Trace
+func GCPProject(id string) Option {
+	return func(config *Config) {
+		config.gcpProject = id
+	}
+}
+
+// LevelForPackage sets the log level of all logger instances in the provided
+// package, or import path prefix. Unlike SetLevelForPackage the package does
+// not need to have created a logger yet
+func LevelForPackage(pkg string, lvl zapcore.Level) Option {
+	return func(config *Config) {
+		if config.pkglevels == nil {
+			config.pkglevels = make(map[string]zapcore.Level)
+		}
+		config.pkglevels[pkg] = lvl
 	}
 }
 
 // Configure will apply all the supplied options to a global configuration
-// that will be applied to all logger instances.
+// that will be applied to all logger instances, including those
+// that have already been created.
 func Configure(options ...Option) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
 	for _, opt := range options {
 		opt(global)
 	}
 
-	registry.SetDefaultLevel(global.level)
+	if global.levelSet {
+		registry.SetDefaultLevel(global.level, registry.SourceConfigure)
+		global.levelSet = false
+	}
+	for pkg, lvl := range global.pkglevels {
+		registry.Declare(registry.Package(pkg), lvl, registry.SourceConfigure)
+	}
+	global.pkglevels = nil
+
+	current.Store(build())
 }
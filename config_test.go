@@ -2,11 +2,19 @@ package logger
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/console"
+	"github.com/syllabix/logger/encode"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/json"
 	"github.com/syllabix/logger/mode"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -93,6 +101,94 @@ func TestConsoleWriter(t *testing.T) {
 	}
 }
 
+func TestConsoleEscaping(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	out := new(bytes.Buffer)
+	Configure(ConsoleWriter(out), Mode(mode.Production), ConsoleEscaping(true))
+
+	New().Info("first\nINFO forged", zap.String("name", "\x1b[31mred\x1b[0m"))
+	assert.Contains(t, out.String(), `message="first\nINFO forged"`)
+	assert.Contains(t, out.String(), "name=red")
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("\n")))
+}
+
+func TestConsoleNamespaces(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	out := new(bytes.Buffer)
+	Configure(ConsoleWriter(out), Mode(mode.Production))
+
+	log := New().With(zap.Namespace("http"), zap.String("method", "GET"))
+	log.Info("dotted", zap.Int("status", 200))
+	assert.Contains(t, out.String(), " http.method=GET http.status=200\n")
+	assert.NotContains(t, out.String(), "@fields")
+
+	out.Reset()
+	Configure(ConsoleNamespaces(console.BracketedNamespaces), ConsoleRootNamespace(true))
+	log.Info("bracketed", zap.Int("status", 200))
+	assert.Contains(t, out.String(), " @fields[http[method=GET status=200]]\n")
+}
+
+func TestConsolePretty(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	out := new(bytes.Buffer)
+	Configure(ConsoleWriter(out), ConsoleColors(encode.ColorAlways), ConsolePretty(true))
+
+	err := fmt.Errorf("read config: %w", errors.New("no such file"))
+	// panics are logged with their stacktrace
+	assert.Panics(t, func() { New().Panic("failed", zap.Error(err)) })
+	assert.Contains(t, out.String(), "    error: read config: no such file\n")
+	assert.Contains(t, out.String(), "caused by:\x1b[0m no such file\n")
+	assert.Contains(t, out.String(), "at github.com/syllabix/logger.TestConsolePretty")
+	assert.NotContains(t, out.String(), "stacktrace=")
+}
+
+func TestConsoleColors(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	// a buffer is not a terminal
+	out := new(bytes.Buffer)
+	Configure(ConsoleWriter(out), Mode(mode.Development))
+	New().Info("plain")
+	assert.NotContains(t, out.String(), "\x1b[")
+
+	out.Reset()
+	Configure(Mode(mode.Production), ConsoleColors(encode.ColorAlways))
+	New().Info("colored")
+	assert.True(t, strings.HasPrefix(out.String(), "\x1b[36mINFO\x1b[0m "))
+	assert.Contains(t, out.String(), "\x1b[36mmessage\x1b[0m=colored")
+}
+
+func TestConsoleTheme(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	out := new(bytes.Buffer)
+	Configure(
+		ConsoleWriter(out),
+		ConsoleColors(encode.ColorAlways),
+		ConsoleTheme(encode.Theme{
+			Levels: map[zapcore.Level]encode.Style{
+				zapcore.InfoLevel: {Foreground: encode.Color256(39), Bold: true},
+			},
+		}),
+	)
+	New().Info("themed")
+	assert.True(t, strings.HasPrefix(out.String(), "\x1b[1;38;5;39mINFO\x1b[0m "))
+	assert.Contains(t, out.String(), "\x1b[1;38;5;39mmessage\x1b[0m=themed")
+}
+
 func TestJSONWriter(t *testing.T) {
 	w := new(bytes.Buffer)
 	type args struct {
@@ -124,6 +220,67 @@ func TestJSONWriter(t *testing.T) {
 	}
 }
 
+func TestJSONSchema(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	cfg := new(Config)
+	JSONSchema(json.ECS)(cfg)
+	assert.Equal(t, json.ECS, cfg.schema)
+
+	console := new(bytes.Buffer)
+	out := new(bytes.Buffer)
+	sink := new(bytes.Buffer)
+	Configure(
+		Mode(mode.Production),
+		AppName("awesome-app"),
+		ConsoleWriter(console),
+		JSONWriter(out),
+		JSONSchema(json.ECS),
+		Sink("file", sink, JSONEncoding()),
+	)
+
+	New().With(zap.String("user", "gopher")).Warn("schema entry")
+
+	for _, written := range []string{out.String(), sink.String()} {
+		assert.Contains(t, written, `"log.level":"warn"`)
+		assert.Contains(t, written, `"message":"schema entry"`)
+		assert.Contains(t, written, `"service.name":"awesome-app"`)
+		assert.Contains(t, written, `"host.hostname":`)
+		assert.Contains(t, written, `"user":"gopher"`)
+		assert.NotContains(t, written, "@fields")
+	}
+
+	// the console output keeps its layout
+	assert.Contains(t, console.String(), "message=schema entry @source_host=")
+	assert.Contains(t, console.String(), "application=awesome-app")
+}
+
+func TestGCPProject(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	out := new(bytes.Buffer)
+	Configure(
+		Mode(mode.Production),
+		ConsoleWriter(nil),
+		JSONWriter(out),
+		JSONSchema(json.GCP),
+		GCPProject("acme"),
+	)
+
+	ctx := WithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	FromContext(ctx).Error("gcp entry")
+
+	assert.Contains(t, out.String(), `"severity":"ERROR"`)
+	assert.Contains(t, out.String(), `"message":"gcp entry"`)
+	assert.Contains(t, out.String(), `"logging.googleapis.com/trace":"projects/acme/traces/4bf92f3577b34da6a3ce929d0e0e4736"`)
+	assert.Contains(t, out.String(), `"logging.googleapis.com/spanId":"00f067aa0ba902b7"`)
+	assert.Contains(t, out.String(), `"logging.googleapis.com/sourceLocation":{"file":"logger/config_test.go"`)
+}
+
 func TestMode(t *testing.T) {
 	type args struct {
 		mode mode.Kind
@@ -165,6 +322,24 @@ func TestMode(t *testing.T) {
 	}
 }
 
+func TestConfigure_keepsDefaultLevel(t *testing.T) {
+	before()
+	defer func() { after(); Configure(Level(zap.InfoLevel)) }()
+	defer registry.Reset()
+
+	// the default level changed at runtime survives unrelated options
+	registry.SetDefaultLevel(zap.DebugLevel, registry.SourceAdmin)
+	Configure(ConsoleWriter(new(bytes.Buffer)))
+	assert.Equal(t, zap.DebugLevel, registry.DefaultLevel())
+	assert.True(t, New().Core().Enabled(zap.DebugLevel))
+
+	Configure(Level(zap.WarnLevel))
+	assert.Equal(t, zap.WarnLevel, registry.DefaultLevel())
+
+	Configure(AppName("app"))
+	assert.Equal(t, zap.WarnLevel, registry.DefaultLevel())
+}
+
 func TestLevel(t *testing.T) {
 	type args struct {
 		lvl zapcore.Level
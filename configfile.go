@@ -0,0 +1,354 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig describes the layout of a configuration file read by
+// ConfigureFromFile. Files ending in .json are decoded as JSON, while
+// .yaml and .yml files are decoded as YAML. Settings that are left out
+// keep their current value
+type FileConfig struct {
+	// Mode is one of development, production or none
+	Mode string `json:"mode" yaml:"mode"`
+	// Level is the default log level
+	Level string `json:"level" yaml:"level"`
+	// AppName is the value of the "application" field
+	AppName string `json:"app_name" yaml:"app_name"`
+	// Levels maps packages, or import path prefixes, to their log level
+	Levels map[string]string `json:"levels" yaml:"levels"`
+	// Console is where console formatted output is written: stdout,
+	// stderr, a file path or none
+	Console string `json:"console" yaml:"console"`
+	// JSON is where json formatted output is written: stdout,
+	// stderr, a file path or none
+	JSON string `json:"json" yaml:"json"`
+	// Keys are the keys used by the console and json encoders
+	Keys EncoderKeys `json:"keys" yaml:"keys"`
+}
+
+// sinkReleaseDelay is how long a sink replaced by a reload is kept open, so
+// that loggers still writing with the previous configuration can finish
+const sinkReleaseDelay = 5 * time.Second
+
+// A FileOption configures how ConfigureFromFile watches a configuration file
+type FileOption func(w *fileWatcher)
+
+// PollInterval sets how often the configuration file is checked for changes,
+// which defaults to every 5 seconds. An interval of zero disables watching
+func PollInterval(d time.Duration) FileOption {
+	return func(w *fileWatcher) {
+		w.interval = d
+	}
+}
+
+// OnReloadError sets the func called when a changed configuration file can
+// not be applied, in which case the current configuration is left in place.
+// By default these errors are written to stderr
+func OnReloadError(fn func(error)) FileOption {
+	return func(w *fileWatcher) {
+		w.onError = fn
+	}
+}
+
+// ConfigureFromFile applies the configuration file at path and keeps polling it
+// for changes, which are applied to all loggers and package levels as they are
+// detected. An error is returned if the file can not be applied initially. The
+// returned func stops watching the file, and closes the files it opened that
+// are no longer configured
+func ConfigureFromFile(path string, options ...FileOption) (stop func(), err error) {
+	w := &fileWatcher{
+		path:     path,
+		interval: 5 * time.Second,
+		onError: func(err error) {
+			fmt.Fprintln(os.Stderr, err)
+		},
+		releaseDelay: sinkReleaseDelay,
+		levels:       make(map[string]zapcore.Level),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("logger: %v", err)
+	}
+
+	if err := w.apply(data); err != nil {
+		return nil, err
+	}
+
+	if w.interval > 0 {
+		go w.watch()
+	} else {
+		close(w.stopped)
+	}
+	return w.close, nil
+}
+
+// sink is a writer opened for a sink name in a configuration file
+type sink struct {
+	name   string
+	writer io.Writer
+}
+
+// release closes the writer if it was opened by the watcher
+func (s sink) release() {
+	if f, ok := s.writer.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+		f.Close()
+	}
+}
+
+// releaseUnlessConfigured releases the sink unless it is still the console
+// or json writer of the global config
+func (s sink) releaseUnlessConfigured() {
+	if s.writer == nil {
+		return
+	}
+
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	if s.writer == global.csink || s.writer == global.jsink {
+		return
+	}
+	s.release()
+}
+
+type fileWatcher struct {
+	path     string
+	interval time.Duration
+	onError  func(error)
+	// releaseDelay is how long replaced sinks are kept open
+	releaseDelay time.Duration
+
+	last    []byte
+	lastErr string
+	console sink
+	json    sink
+	// levels are the package levels applied from the file
+	levels map[string]zapcore.Level
+
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+func (w *fileWatcher) watch() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.reload()
+		}
+	}
+}
+
+// close stops watching the file and releases the sinks opened by the watcher,
+// unless they are still configured
+func (w *fileWatcher) close() {
+	w.once.Do(func() {
+		close(w.done)
+		<-w.stopped
+
+		w.console.releaseUnlessConfigured()
+		w.json.releaseUnlessConfigured()
+	})
+}
+
+// reload applies the file when its contents changed since the last attempt
+func (w *fileWatcher) reload() {
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		w.report(fmt.Errorf("logger: %v", err))
+		return
+	}
+
+	if bytes.Equal(data, w.last) {
+		return
+	}
+
+	if err := w.apply(data); err != nil {
+		w.report(err)
+		return
+	}
+	w.lastErr = ""
+}
+
+// report calls the error handler, skipping errors that were just reported
+func (w *fileWatcher) report(err error) {
+	if err.Error() == w.lastErr {
+		return
+	}
+	w.lastErr = err.Error()
+	w.onError(err)
+}
+
+// apply validates the configuration in data and applies it as a whole,
+// leaving the current configuration untouched if it is invalid
+func (w *fileWatcher) apply(data []byte) error {
+	w.last = data
+
+	cfg, err := parseFileConfig(w.path, data)
+	if err != nil {
+		return fmt.Errorf("logger: %s: %v", w.path, err)
+	}
+
+	options, levels, err := cfg.options()
+	if err != nil {
+		return fmt.Errorf("logger: %s: %v", w.path, err)
+	}
+
+	consoleSink, err := w.open(w.console, cfg.Console)
+	if err != nil {
+		return fmt.Errorf("logger: %s: console: %v", w.path, err)
+	}
+
+	jsonSink, err := w.open(w.json, cfg.JSON)
+	if err != nil {
+		if consoleSink != w.console {
+			consoleSink.release()
+		}
+		return fmt.Errorf("logger: %s: json: %v", w.path, err)
+	}
+
+	if len(cfg.Console) > 0 {
+		options = append(options, ConsoleWriter(consoleSink.writer))
+	}
+	if len(cfg.JSON) > 0 {
+		options = append(options, JSONWriter(jsonSink.writer))
+	}
+
+	Configure(options...)
+	w.applyLevels(levels)
+
+	// loggers may still be writing to replaced sinks with the previous
+	// configuration, so they are released once that had time to finish
+	if consoleSink != w.console {
+		time.AfterFunc(w.releaseDelay, w.console.releaseUnlessConfigured)
+		w.console = consoleSink
+	}
+	if jsonSink != w.json {
+		time.AfterFunc(w.releaseDelay, w.json.releaseUnlessConfigured)
+		w.json = jsonSink
+	}
+	return nil
+}
+
+// open returns the sink for name, reusing the current one if it is unchanged
+func (w *fileWatcher) open(current sink, name string) (sink, error) {
+	if len(name) == 0 || name == current.name {
+		return current, nil
+	}
+	if name == "none" {
+		return sink{name: name}, nil
+	}
+
+	writer, err := openSink(name)
+	if err != nil {
+		return sink{}, err
+	}
+	return sink{name: name, writer: writer}, nil
+}
+
+// applyLevels declares package levels that changed since the file was last
+// applied, and clears those that were removed from it. Levels that did not
+// change in the file are left alone, so they can be changed at runtime
+func (w *fileWatcher) applyLevels(levels map[string]zapcore.Level) {
+	for pkg, lvl := range levels {
+		if prev, ok := w.levels[pkg]; !ok || prev != lvl {
+			registry.Declare(registry.Package(pkg), lvl, registry.SourceFile)
+		}
+	}
+
+	for pkg := range w.levels {
+		if _, ok := levels[pkg]; !ok {
+			_ = registry.Clear(registry.Package(pkg), registry.SourceFile)
+		}
+	}
+	w.levels = levels
+}
+
+func parseFileConfig(path string, data []byte) (FileConfig, error) {
+	var cfg FileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, err
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+			return cfg, err
+		}
+	default:
+		return cfg, fmt.Errorf("unsupported file extension %q", ext)
+	}
+	return cfg, nil
+}
+
+// options validates the settings of the file that can be applied through an
+// Option, returning them along with the package levels
+func (cfg FileConfig) options() ([]Option, map[string]zapcore.Level, error) {
+	var options []Option
+
+	if len(cfg.Mode) > 0 {
+		m, err := mode.Parse(cfg.Mode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("mode: %v", err)
+		}
+		options = append(options, Mode(m))
+	}
+
+	if len(cfg.Level) > 0 {
+		lvl, err := parseLevel(cfg.Level)
+		if err != nil {
+			return nil, nil, fmt.Errorf("level: %v", err)
+		}
+		options = append(options, Level(lvl))
+	}
+
+	if len(cfg.AppName) > 0 {
+		options = append(options, AppName(cfg.AppName))
+	}
+
+	if !cfg.Keys.isZero() {
+		options = append(options, Keys(cfg.Keys))
+	}
+
+	levels := make(map[string]zapcore.Level, len(cfg.Levels))
+	for pkg, name := range cfg.Levels {
+		lvl, err := parseLevel(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("levels: package %s: %v", pkg, err)
+		}
+		levels[pkg] = lvl
+	}
+	return options, levels, nil
+}
@@ -0,0 +1,282 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// snapshot returns a copy of the global config that is safe to
+// inspect while a file watcher may be applying changes
+func snapshot() Config {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	return *global
+}
+
+func eventually(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigureFromFile(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logger.yaml")
+	logfile := filepath.Join(dir, "app.log")
+
+	writeFile(t, path, `
+mode: production
+level: warn
+app_name: file-app
+console: `+logfile+`
+levels:
+  github.com/acme/db: debug
+  github.com/acme/http: error
+keys:
+  message: msg
+`)
+
+	errs := make(chan error, 1)
+	stop, err := ConfigureFromFile(path,
+		PollInterval(10*time.Millisecond),
+		OnReloadError(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}),
+	)
+	assert.NoError(t, err)
+	defer stop()
+
+	cfg := snapshot()
+	assert.Equal(t, mode.Production, cfg.mode)
+	assert.Equal(t, zap.WarnLevel, cfg.level)
+	assert.Equal(t, "file-app", cfg.appname)
+	assert.Equal(t, "msg", cfg.keys.Message)
+	assert.Equal(t, zap.DebugLevel, registry.Get("github.com/acme/db/sql").Level())
+	assert.Equal(t, zap.ErrorLevel, registry.Get("github.com/acme/http").Level())
+
+	New().Warn("written to file")
+	contents, err := ioutil.ReadFile(logfile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "msg=written to file")
+	assert.Contains(t, string(contents), "application=file-app")
+
+	// changes are picked up and package levels removed from the file are cleared
+	writeFile(t, path, `
+mode: development
+level: debug
+app_name: file-app
+console: stderr
+levels:
+  github.com/acme/db: info
+`)
+	eventually(t, func() bool { return snapshot().mode == mode.Development })
+	cfg = snapshot()
+	assert.Equal(t, zap.DebugLevel, cfg.level)
+	assert.Equal(t, os.Stderr, cfg.csink)
+	eventually(t, func() bool {
+		return registry.Get("github.com/acme/http").Level() == zap.DebugLevel
+	})
+	assert.Equal(t, zap.InfoLevel, registry.Get("github.com/acme/db/sql").Level())
+
+	// invalid changes are reported and leave the configuration in place
+	writeFile(t, path, `
+mode: staging
+level: error
+`)
+	select {
+	case err := <-errs:
+		assert.EqualError(t, err, "logger: "+path+`: mode: unrecognized mode: "staging"`)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a reload error")
+	}
+	cfg = snapshot()
+	assert.Equal(t, mode.Development, cfg.mode)
+	assert.Equal(t, zap.DebugLevel, cfg.level)
+}
+
+func TestConfigureFromFile_sinks(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logger.json")
+	first := filepath.Join(dir, "first.log")
+	second := filepath.Join(dir, "second.log")
+
+	writeFile(t, path, `{"json": "`+first+`"}`)
+	stop, err := ConfigureFromFile(path,
+		PollInterval(10*time.Millisecond),
+		func(w *fileWatcher) { w.releaseDelay = 50 * time.Millisecond },
+	)
+	assert.NoError(t, err)
+	firstFile := snapshot().jsink.(*os.File)
+
+	// a replaced sink stays open for loggers still writing to it
+	writeFile(t, path, `{"json": "`+second+`"}`)
+	eventually(t, func() bool { return snapshot().jsink != firstFile })
+	_, err = firstFile.Write([]byte("late\n"))
+	assert.NoError(t, err)
+
+	// and is closed once they had time to finish
+	eventually(t, func() bool {
+		_, err := firstFile.Write([]byte("later\n"))
+		return err != nil
+	})
+
+	// stopping leaves the configured sink open
+	secondFile := snapshot().jsink.(*os.File)
+	stop()
+	_, err = secondFile.Write([]byte("still open\n"))
+	assert.NoError(t, err)
+	secondFile.Close()
+
+	// but closes sinks that are no longer configured
+	writeFile(t, path, `{"json": "`+first+`"}`)
+	stop, err = ConfigureFromFile(path, PollInterval(0))
+	assert.NoError(t, err)
+	firstFile = snapshot().jsink.(*os.File)
+	Configure(JSONWriter(os.Stderr))
+	stop()
+	_, err = firstFile.Write([]byte("closed\n"))
+	assert.Error(t, err)
+}
+
+func TestConfigureFromFile_Invalid(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		wantErr  string
+	}{
+		{
+			name:     "unknown json field",
+			file:     "logger.json",
+			contents: `{"mode": "production", "colour": "blue"}`,
+			wantErr:  `json: unknown field "colour"`,
+		},
+		{
+			name:     "unknown yaml field",
+			file:     "logger.yml",
+			contents: "colour: blue\n",
+			wantErr:  "yaml: unmarshal errors:\n  line 1: field colour not found in type logger.FileConfig",
+		},
+		{
+			name:     "invalid package level",
+			file:     "levels.json",
+			contents: `{"levels": {"main": "chatty"}}`,
+			wantErr:  `levels: package main: unrecognized level: "chatty"`,
+		},
+		{
+			name:     "unsupported extension",
+			file:     "logger.toml",
+			contents: `mode = "production"`,
+			wantErr:  `unsupported file extension ".toml"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			writeFile(t, path, tt.contents)
+
+			stop, err := ConfigureFromFile(path, PollInterval(0))
+			assert.Nil(t, stop)
+			assert.EqualError(t, err, "logger: "+path+": "+tt.wantErr)
+		})
+	}
+
+	_, err = ConfigureFromFile(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func Test_parseFileConfig_malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{name: "yaml", file: "logger.yaml", data: "0: [:!00 \xef"},
+		{name: "unterminated yaml", file: "logger.yml", data: "mode: [production"},
+		{name: "json", file: "logger.json", data: `{"mode": `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			assert.NotPanics(t, func() {
+				_, err = parseFileConfig(tt.file, []byte(tt.data))
+			})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFileConfig_options(t *testing.T) {
+	cfg := FileConfig{
+		Mode:    "pro",
+		Level:   "error",
+		AppName: "app",
+		Levels:  map[string]string{"main": "debug"},
+		Keys:    EncoderKeys{Caller: "src"},
+	}
+
+	options, levels, err := cfg.options()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]zapcore.Level{"main": zap.DebugLevel}, levels)
+
+	got := new(Config)
+	for _, opt := range options {
+		opt(got)
+	}
+	assert.Equal(t, Config{
+		mode:     mode.Production,
+		level:    zap.ErrorLevel,
+		levelSet: true,
+		appname:  "app",
+		keys:     EncoderKeys{Caller: "src"},
+	}, *got)
+}
@@ -0,0 +1,151 @@
+package console
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// open starts an array or object
+func (e *Encoder) open(bracket byte) {
+	e.buf.AppendByte(bracket)
+	e.depth++
+	e.sep = false
+}
+
+// close ends an array or object, which is followed by a separator if it is
+// an element itself
+func (e *Encoder) close(bracket byte) {
+	e.buf.AppendByte(bracket)
+	e.depth--
+	e.sep = true
+}
+
+// element writes the separator ahead of an element of an array or object,
+// unless it is the first
+func (e *Encoder) element() {
+	if e.sep {
+		e.buf.AppendString(", ")
+	}
+	e.sep = true
+}
+
+// arrayEncoder writes the elements of an array separated by commas
+type arrayEncoder struct {
+	e *Encoder
+}
+
+func (a arrayEncoder) AppendBool(val bool) {
+	a.e.element()
+	a.e.AppendBool(val)
+}
+
+func (a arrayEncoder) AppendByteString(val []byte) {
+	a.e.element()
+	a.e.AppendByteString(val)
+}
+
+func (a arrayEncoder) AppendComplex128(val complex128) {
+	a.e.element()
+	a.e.AppendComplex128(val)
+}
+
+func (a arrayEncoder) AppendComplex64(val complex64) {
+	a.e.element()
+	a.e.AppendComplex64(val)
+}
+
+func (a arrayEncoder) AppendFloat64(val float64) {
+	a.e.element()
+	a.e.AppendFloat64(val)
+}
+
+func (a arrayEncoder) AppendFloat32(val float32) {
+	a.e.element()
+	a.e.AppendFloat32(val)
+}
+
+func (a arrayEncoder) AppendInt(val int) {
+	a.e.element()
+	a.e.AppendInt(val)
+}
+
+func (a arrayEncoder) AppendInt64(val int64) {
+	a.e.element()
+	a.e.AppendInt64(val)
+}
+
+func (a arrayEncoder) AppendInt32(val int32) {
+	a.e.element()
+	a.e.AppendInt32(val)
+}
+
+func (a arrayEncoder) AppendInt16(val int16) {
+	a.e.element()
+	a.e.AppendInt16(val)
+}
+
+func (a arrayEncoder) AppendInt8(val int8) {
+	a.e.element()
+	a.e.AppendInt8(val)
+}
+
+func (a arrayEncoder) AppendString(val string) {
+	a.e.element()
+	a.e.AppendString(val)
+}
+
+func (a arrayEncoder) AppendUint(val uint) {
+	a.e.element()
+	a.e.AppendUint(val)
+}
+
+func (a arrayEncoder) AppendUint64(val uint64) {
+	a.e.element()
+	a.e.AppendUint64(val)
+}
+
+func (a arrayEncoder) AppendUint32(val uint32) {
+	a.e.element()
+	a.e.AppendUint32(val)
+}
+
+func (a arrayEncoder) AppendUint16(val uint16) {
+	a.e.element()
+	a.e.AppendUint16(val)
+}
+
+func (a arrayEncoder) AppendUint8(val uint8) {
+	a.e.element()
+	a.e.AppendUint8(val)
+}
+
+func (a arrayEncoder) AppendUintptr(val uintptr) {
+	a.e.element()
+	a.e.AppendUintptr(val)
+}
+
+func (a arrayEncoder) AppendDuration(val time.Duration) {
+	a.e.element()
+	a.e.AppendDuration(val)
+}
+
+func (a arrayEncoder) AppendTime(val time.Time) {
+	a.e.element()
+	a.e.AppendTime(val)
+}
+
+func (a arrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	a.e.element()
+	return a.e.AppendArray(arr)
+}
+
+func (a arrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	a.e.element()
+	return a.e.AppendObject(obj)
+}
+
+func (a arrayEncoder) AppendReflected(val interface{}) error {
+	a.e.element()
+	return a.e.AppendReflected(val)
+}
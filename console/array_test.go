@@ -0,0 +1,112 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/encode"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// role is an object with an array of its own
+type role struct {
+	name   string
+	scopes []string
+}
+
+func (r role) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", r.name)
+	return enc.AddArray("scopes", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, s := range r.scopes {
+			arr.AppendString(s)
+		}
+		return nil
+	}))
+}
+
+type roles []role
+
+func (r roles) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, role := range r {
+		if err := enc.AppendObject(role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matrix is an array of arrays
+type matrix [][]int
+
+func (m matrix) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, row := range m {
+		if err := enc.AppendArray(zap.Ints("", row).Interface.(zapcore.ArrayMarshaler)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestEncoder_collections(t *testing.T) {
+	tests := []struct {
+		name   string
+		escape bool
+		fields []zapcore.Field
+		want   string
+	}{
+		{
+			name:   "array",
+			fields: []zapcore.Field{zap.Strings("tags", []string{"a", "b", "c"}), zap.String("after", "yes")},
+			want:   "tags=[a, b, c] after=yes",
+		},
+		{
+			name:   "object",
+			fields: []zapcore.Field{zap.Object("role", role{name: "admin", scopes: []string{"read", "write"}})},
+			want:   "role={name=admin, scopes=[read, write]}",
+		},
+		{
+			name: "array of objects",
+			fields: []zapcore.Field{zap.Array("roles", roles{
+				{name: "admin", scopes: []string{"read"}},
+				{name: "guest"},
+			})},
+			want: "roles=[{name=admin, scopes=[read]}, {name=guest, scopes=[]}]",
+		},
+		{
+			name:   "array of arrays",
+			fields: []zapcore.Field{zap.Array("matrix", matrix{{1, 2}, {3}, {}})},
+			want:   "matrix=[[1, 2], [3], []]",
+		},
+		{
+			name:   "empty collections",
+			fields: []zapcore.Field{zap.Strings("tags", nil), zap.Object("role", zapcore.ObjectMarshalerFunc(func(zapcore.ObjectEncoder) error { return nil }))},
+			want:   "tags=[] role={}",
+		},
+		{
+			name:   "escaped elements",
+			escape: true,
+			fields: []zapcore.Field{zap.Strings("tags", []string{"a, b", "{c}", "d e", ""})},
+			want:   `tags=["a, b", "{c}", "d e", ""]`,
+		},
+	}
+	for _, tt := range tests {
+		for _, m := range []mode.Kind{mode.Production, mode.Development} {
+			t.Run(tt.name+"/"+m.String(), func(t *testing.T) {
+				cfg := *encode.ProConsoleConfig
+				if m == mode.Development {
+					cfg = *encode.DevConsoleConfig
+				}
+				cfg.MessageKey = ""
+				enc := NewEncoder(Config{Config: &cfg, Mode: m, Escape: tt.escape})
+
+				got, err := enc.EncodeEntry(zapcore.Entry{}, tt.fields)
+				assert.NoError(t, err)
+				// collections are rendered the same way in both modes, apart
+				// from the colored top level keys
+				assert.Equal(t, "INFO 0001-01-01T00:00:00.000Z "+tt.want+"\n", encode.StripANSI(got.String()))
+			})
+		}
+	}
+}
@@ -13,8 +13,54 @@ import (
 type Config struct {
 	Config *zapcore.EncoderConfig
 	Mode   mode.Kind
+	// Escape quotes messages and field values when needed, escapes their
+	// control characters and strips ANSI escape sequences from them, so
+	// every entry is written as a single line of key=value pairs. The
+	// coloring of the encoder itself is kept
+	Escape bool
+	// Namespaces is how fields in namespaces are rendered
+	Namespaces NamespaceStyle
+	// HideRoot leaves the outermost namespace, such as the @fields namespace
+	// opened by the logger package, out of the rendered keys
+	HideRoot bool
+	// Pretty writes stacktraces below the entry in development mode, one
+	// frame per line, along with the chain of errors wrapped by error fields
+	Pretty bool
+	// Module is the import path of the module whose stack frames are
+	// highlighted in pretty output
+	Module string
+	// Colors is whether keys and pretty output are colored, and Theme how,
+	// encode.DefaultTheme when nil. The level is encoded by the EncodeLevel
+	// function of Config, such as Theme.ColorLevel
+	Colors Colors
+	Theme  *encode.Theme
 }
 
+// Colors is whether the console encoder colors its output
+type Colors int8
+
+// Supported color settings
+const (
+	// ModeColors colors output in development mode only
+	ModeColors Colors = iota
+	// AlwaysColors colors output in any mode
+	AlwaysColors
+	// NeverColors never colors output
+	NeverColors
+)
+
+// NamespaceStyle is how the console encoder renders fields in namespaces
+type NamespaceStyle int8
+
+// Supported namespace styles
+const (
+	// DottedNamespaces prefixes keys with their namespaces: http.status=200
+	DottedNamespaces NamespaceStyle = iota
+	// BracketedNamespaces groups the fields of a namespace in brackets:
+	// http[method=GET status=200]
+	BracketedNamespaces
+)
+
 // Encoder is a bol.com tailored zap encoder for
 // writing human readable logs to the console
 type Encoder struct {
@@ -22,12 +68,37 @@ type Encoder struct {
 	buf    *buffer.Buffer
 	level  zapcore.Level
 	mode   mode.Kind
+	escape bool
+	colors Colors
+	theme  *encode.Theme
+	pretty bool
+	module string
+	// trusted is set while the encoder writes its own entry values, such
+	// as the colored level, which are not escaped
+	trusted bool
+
+	ns namespaces
+	// depth is the number of arrays and objects being written, and sep is
+	// set when the next element of the innermost one needs a separator
+	depth int
+	sep   bool
+	// braces is the number of namespaces opened in the current object
+	braces int
+	// keys are the spans of buf holding keys. Keys are written without
+	// color, and colored in the style of the level of an entry once it is
+	// encoded, so the context of an encoder can be shared by all levels
+	keys []span
+}
+
+// span is a range of bytes in a buffer
+type span struct {
+	start, end int
 }
 
 // Clone implements the Clone method of the zapcore Encoder interface
 func (e *Encoder) Clone() zapcore.Encoder {
 	clone := e.clone(e.level)
-	clone.buf.Write(e.buf.Bytes())
+	clone.write(e.buf.Bytes(), e.keys)
 	return clone
 }
 
@@ -36,6 +107,12 @@ func (e *Encoder) clone(level zapcore.Level) *Encoder {
 	clone.config = e.config
 	clone.level = level
 	clone.mode = e.mode
+	clone.escape = e.escape
+	clone.colors = e.colors
+	clone.theme = e.theme
+	clone.pretty = e.pretty
+	clone.module = e.module
+	clone.ns = e.ns.clone()
 	clone.buf = bufferpool.Get()
 	return clone
 }
@@ -44,20 +121,90 @@ func (e *Encoder) devmode() bool {
 	return e.mode == mode.Development
 }
 
-func (e *Encoder) write(buffer []byte) {
-	if e.devmode() {
-		recolor(buffer, e.level)
+func (e *Encoder) colored() bool {
+	switch e.colors {
+	case AlwaysColors:
+		return true
+	case NeverColors:
+		return false
+	default:
+		return e.devmode()
 	}
+}
+
+func (e *Encoder) styles() *encode.Theme {
+	if e.theme == nil {
+		return &encode.DefaultTheme
+	}
+	return e.theme
+}
+
+// write copies the contents of another encoder, along with the spans of its
+// keys
+func (e *Encoder) write(buffer []byte, keys []span) {
+	offset := e.buf.Len()
 	e.buf.Write(buffer)
+	for _, k := range keys {
+		e.keys = append(e.keys, span{start: offset + k.start, end: offset + k.end})
+	}
+}
+
+// appendKey writes a key, which is colored when the entry is encoded
+func (e *Encoder) appendKey(key string) {
+	start := e.buf.Len()
+	e.buf.AppendString(key)
+	if e.colored() {
+		e.keys = append(e.keys, span{start: start, end: e.buf.Len()})
+	}
+}
+
+// reset ends the style of a key
+const reset = "\x1b[0m"
+
+// colorKeys returns the contents of the encoder with its keys in the style of
+// its level. The buffer of the encoder is returned as is when there is
+// nothing to color, and freed otherwise
+func (e *Encoder) colorKeys() *buffer.Buffer {
+	seq := e.styles().Level(e.level).Sequence()
+	if len(e.keys) == 0 || isEmpty(seq) {
+		return e.buf
+	}
+
+	colored := bufferpool.Get()
+	b := e.buf.Bytes()
+	last := 0
+	for _, k := range e.keys {
+		colored.Write(b[last:k.start])
+		colored.AppendString(seq)
+		colored.Write(b[k.start:k.end])
+		colored.AppendString(reset)
+		last = k.end
+	}
+	colored.Write(b[last:])
+	e.buf.Free()
+	return colored
 }
 
 func (e *Encoder) addKey(key string) {
-	e.buf.AppendByte(' ')
-	if e.devmode() {
-		e.buf.AppendString(encode.ColorKey(key, e.level))
-	} else {
+	// keys in objects are neither prefixed nor colored
+	if e.depth > 0 {
+		e.element()
+		if e.escape {
+			key = escapeKey(key)
+		}
 		e.buf.AppendString(key)
+		e.buf.AppendByte('=')
+		return
+	}
+
+	e.separate()
+	if e.ns.style == DottedNamespaces {
+		key = e.ns.prefix + key
 	}
+	if e.escape {
+		key = escapeKey(key)
+	}
+	e.appendKey(key)
 	e.buf.AppendByte('=')
 }
 
@@ -66,6 +213,11 @@ func (e *Encoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffe
 	final := e.clone(ent.Level)
 	config := final.config
 
+	// entry keys are written outside of any namespace
+	context := final.ns
+	final.ns = namespaces{style: context.style, hideRoot: context.hideRoot}
+
+	final.trusted = true
 	config.EncodeLevel(ent.Level, final)
 	final.buf.AppendByte(' ')
 
@@ -97,31 +249,52 @@ func (e *Encoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffe
 			final.AppendString(ent.Caller.String())
 		}
 	}
+	final.trusted = false
 
 	if !isEmpty(config.MessageKey) {
 		final.addKey(config.MessageKey)
 		final.AppendString(ent.Message)
 	}
 
+	// the context of the encoder, and the namespaces it opened, come ahead
+	// of the fields of the entry, which are added in those namespaces
+	final.ns = context
+	if e.buf.Len() > 0 {
+		final.write(e.buf.Bytes(), e.keys)
+	}
+
+	// in pretty output, errors wrapping others are written below the entry
+	pretty := final.pretty && final.devmode()
+	var wrapped []zapcore.Field
 	for i := range fields {
+		if pretty && wrapping(fields[i]) != nil {
+			wrapped = append(wrapped, fields[i])
+			continue
+		}
 		fields[i].AddTo(final)
 	}
 
-	if final.buf.Len() > 0 {
-		final.write(e.buf.Bytes())
-	}
+	final.closeNamespaces(0)
 
-	if !isEmpty(ent.Stack) && !isEmpty(config.StacktraceKey) {
+	stack := !isEmpty(ent.Stack) && !isEmpty(config.StacktraceKey)
+	if stack && !pretty {
 		final.AddString(config.StacktraceKey, ent.Stack)
 	}
 
-	if !isEmpty(config.LineEnding) {
-		final.buf.AppendString(config.LineEnding)
-	} else {
-		final.buf.AppendString("\n")
+	lineEnding := config.LineEnding
+	if isEmpty(lineEnding) {
+		lineEnding = "\n"
+	}
+	final.buf.AppendString(lineEnding)
+
+	for _, f := range wrapped {
+		final.writeCauses(f.Key, wrapping(f), lineEnding)
+	}
+	if stack && pretty {
+		final.writeStack(ent.Stack, lineEnding)
 	}
 
-	ret := final.buf
+	ret := final.colorKeys()
 	put(final)
 
 	return ret, nil
@@ -144,5 +317,14 @@ func NewEncoder(cfg Config) *Encoder {
 		buf:    bufferpool.Get(),
 		mode:   cfg.Mode,
 		config: cfg.Config,
+		escape: cfg.Escape,
+		colors: cfg.Colors,
+		theme:  cfg.Theme,
+		pretty: cfg.Pretty,
+		module: cfg.Module,
+		ns: namespaces{
+			style:    cfg.Namespaces,
+			hideRoot: cfg.HideRoot,
+		},
 	}
 }
@@ -1,7 +1,6 @@
 package console
 
 import (
-	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -9,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/syllabix/logger/encode"
 	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
 	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/zapcore"
 )
@@ -172,100 +172,75 @@ var pro_cfg = Config{
 }
 
 func TestEncoder_write(t *testing.T) {
+	ctx := NewEncoder(dev_cfg)
+	ctx.AddString("env", "dev")
+	ctx.AddString("app", "core-app")
+
+	e := NewEncoder(dev_cfg)
+	e.AddString("host", "los.12314")
+	e.write(ctx.buf.Bytes(), ctx.keys)
+
+	assert.Equal(t, " host=los.12314 env=dev app=core-app", e.buf.String())
+	assert.Equal(t, []span{{1, 5}, {16, 19}, {24, 27}}, e.keys)
+}
+
+func TestEncoder_colorKeys(t *testing.T) {
 	tests := []struct {
-		name   string
-		enc    *Encoder
-		buffer []byte
-		want   string
+		name  string
+		cfg   Config
+		level zapcore.Level
+		want  string
 	}{
 		{
-			name: "dev mode debug level",
-			enc: func() *Encoder {
-				e := NewEncoder(dev_cfg)
-				e.level = zapcore.DebugLevel
-				return e
-			}(),
-			buffer: func() []byte {
-				b := fmt.Sprintf("%s=dev %s=core-app %s=los.12314",
-					encode.Cyan.Add("env"),
-					encode.Cyan.Add("app"),
-					encode.Cyan.Add("host"))
-
-				return []byte(b)
-			}(),
-			want: "\x1b[35menv\x1b[0m=dev \x1b[35mapp\x1b[0m=core-app \x1b[35mhost\x1b[0m=los.12314",
+			name:  "dev mode debug level",
+			cfg:   dev_cfg,
+			level: zapcore.DebugLevel,
+			want:  " \x1b[35menv\x1b[0m=\x1b[31mdev\x1b[0m \x1b[35mapp\x1b[0m=core-app",
 		},
 		{
-			name: "dev mode info level",
-			enc: func() *Encoder {
-				e := NewEncoder(dev_cfg)
-				e.level = zapcore.InfoLevel
-				return e
-			}(),
-			buffer: func() []byte {
-				b := fmt.Sprintf("%s=dev %s=core-app %s=los.12314",
-					encode.Red.Add("env"),
-					encode.Red.Add("app"),
-					encode.Red.Add("host"))
-
-				return []byte(b)
-			}(),
-			want: "\x1b[36menv\x1b[0m=dev \x1b[36mapp\x1b[0m=core-app \x1b[36mhost\x1b[0m=los.12314",
+			name:  "dev mode info level",
+			cfg:   dev_cfg,
+			level: zapcore.InfoLevel,
+			want:  " \x1b[36menv\x1b[0m=\x1b[31mdev\x1b[0m \x1b[36mapp\x1b[0m=core-app",
 		},
 		{
-			name: "dev mode warn level",
-			enc: func() *Encoder {
-				e := NewEncoder(dev_cfg)
-				e.level = zapcore.WarnLevel
-				return e
-			}(),
-			buffer: func() []byte {
-				b := fmt.Sprintf("%s=dev %s=core-app %s=los.12314",
-					encode.Cyan.Add("env"),
-					encode.Cyan.Add("app"),
-					encode.Cyan.Add("host"))
-
-				return []byte(b)
-			}(),
-			want: "\x1b[33menv\x1b[0m=dev \x1b[33mapp\x1b[0m=core-app \x1b[33mhost\x1b[0m=los.12314",
+			name:  "dev mode warn level",
+			cfg:   dev_cfg,
+			level: zapcore.WarnLevel,
+			want:  " \x1b[33menv\x1b[0m=\x1b[31mdev\x1b[0m \x1b[33mapp\x1b[0m=core-app",
 		},
 		{
-			name: "dev mode error level",
-			enc: func() *Encoder {
-				e := NewEncoder(dev_cfg)
-				e.level = zapcore.ErrorLevel
-				return e
-			}(),
-			buffer: func() []byte {
-				b := fmt.Sprintf("%s=dev %s=core-app %s=los.12314",
-					encode.Cyan.Add("env"),
-					encode.Cyan.Add("app"),
-					encode.Cyan.Add("host"))
-
-				return []byte(b)
-			}(),
-			want: "\x1b[31menv\x1b[0m=dev \x1b[31mapp\x1b[0m=core-app \x1b[31mhost\x1b[0m=los.12314",
+			name:  "dev mode error level",
+			cfg:   dev_cfg,
+			level: zapcore.ErrorLevel,
+			want:  " \x1b[31menv\x1b[0m=\x1b[31mdev\x1b[0m \x1b[31mapp\x1b[0m=core-app",
 		},
 		{
-			name: "pro mode info level",
-			enc: func() *Encoder {
-				e := NewEncoder(pro_cfg)
-				e.level = zapcore.InfoLevel
-				return e
-			}(),
-			buffer: func() []byte {
-				b := "env=dev app=core-app host=los.12314"
-
-				return []byte(b)
-			}(),
-			want: "env=dev app=core-app host=los.12314",
+			name: "themed warn level",
+			cfg: Config{Config: a_config, Colors: AlwaysColors, Theme: &encode.Theme{
+				Levels: map[zapcore.Level]encode.Style{
+					zapcore.WarnLevel: {Foreground: encode.Color256(208), Bold: true},
+				},
+			}},
+			level: zapcore.WarnLevel,
+			want:  " \x1b[1;38;5;208menv\x1b[0m=\x1b[31mdev\x1b[0m \x1b[1;38;5;208mapp\x1b[0m=core-app",
+		},
+		{
+			name:  "pro mode info level",
+			cfg:   pro_cfg,
+			level: zapcore.InfoLevel,
+			want:  " env=\x1b[31mdev\x1b[0m app=core-app",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.enc.write(tt.buffer)
-			actual := tt.enc.buf.String()
-			assert.Equal(t, tt.want, actual)
+			e := NewEncoder(tt.cfg)
+			// sequences in values are left untouched
+			e.AddString("env", encode.Red.Add("dev"))
+			e.AddString("app", "core-app")
+			e.level = tt.level
+
+			assert.Equal(t, tt.want, e.colorKeys().String())
 		})
 	}
 }
@@ -494,6 +469,30 @@ func TestEncoder_EncodeEntry(t *testing.T) {
 			}(),
 			wantErr: false,
 		},
+		{
+			name: "trace pro mode",
+			fields: fields{
+				config: a_config,
+				buf:    bufferpool.Get(),
+				mode:   mode.Production,
+			},
+			args: args{
+				ent: warn_entry,
+				fields: []zapcore.Field{
+					encode.TraceField(encode.Trace{
+						TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+						SpanID:  "00f067aa0ba902b7",
+					}),
+				},
+			},
+			want: func() *buffer.Buffer {
+				b := bufferpool.Get()
+				str := "WARN 2020-03-22T13:42:12.000Z caller=buzz.go:18 message=excellent day for a bike ride trace=4bf92f3577b34da6a3ce929d0e0e4736/00f067aa0ba902b7\n"
+				b.Write([]byte(str))
+				return b
+			}(),
+			wantErr: false,
+		},
 		{
 			name: "error dev mode",
 			fields: fields{
@@ -582,3 +581,118 @@ func TestEncoder_EncodeEntry(t *testing.T) {
 		})
 	}
 }
+
+func TestEncoder_EncodeEntry_escape(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    mode.Kind
+		message string
+		stack   string
+		fields  []zapcore.Field
+		want    string
+	}{
+		{
+			name:    "plain values",
+			mode:    mode.Production,
+			message: "hello",
+			fields:  []zapcore.Field{zap.String("user", "gopher"), zap.Int("count", 2)},
+			want:    "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello user=gopher count=2\n",
+		},
+		{
+			name:    "quoting",
+			mode:    mode.Production,
+			message: "hello world",
+			fields:  []zapcore.Field{zap.String("query", `a="b c"`), zap.String("empty", ""), zap.String("bad key", "v")},
+			want:    `INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message="hello world" query="a=\"b c\"" empty="" bad_key=v` + "\n",
+		},
+		{
+			name:    "injected lines",
+			mode:    mode.Production,
+			message: "login failed\nINFO 2020-03-22T13:42:13.000Z message=login succeeded",
+			want:    `INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message="login failed\nINFO 2020-03-22T13:42:13.000Z message=login succeeded"` + "\n",
+		},
+		{
+			name:    "stacktrace on a single line",
+			mode:    mode.Production,
+			message: "panic",
+			stack:   "main.main()\n\t/src/main.go:10",
+			want:    `INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=panic stacktrace="main.main()\n\t/src/main.go:10"` + "\n",
+		},
+		{
+			name:    "ansi sequences in dev mode",
+			mode:    mode.Development,
+			message: "\x1b[31mred\x1b[0m \x1b]0;title\x07done\x1b",
+			fields:  []zapcore.Field{zap.Any("reflected", "\x1b[2Jclear")},
+			want:    "\x1b[36mINFO\x1b[0m 2020-03-22T13:42:12.000Z \x1b[36mcaller\x1b[0m=foo.go:18 \x1b[36mmessage\x1b[0m=\"red done\\u001b\" \x1b[36mreflected\x1b[0m=clear\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *encode.ProConsoleConfig
+			if tt.mode == mode.Development {
+				cfg = *encode.DevConsoleConfig
+			}
+			e := NewEncoder(Config{Config: &cfg, Mode: tt.mode, Escape: true})
+
+			ent := info_entry
+			ent.Message = tt.message
+			ent.Stack = tt.stack
+			got, err := e.EncodeEntry(ent, tt.fields)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestEncoder_EncodeEntry_colors(t *testing.T) {
+	theme := &encode.Theme{
+		Levels: map[zapcore.Level]encode.Style{
+			zapcore.InfoLevel: {Foreground: encode.TrueColor(0, 128, 255)},
+		},
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			name: "production with colors",
+			cfg:  Config{Config: encode.ProConsoleConfig, Mode: mode.Production, Colors: AlwaysColors},
+			want: "INFO 2020-03-22T13:42:12.000Z \x1b[36mcaller\x1b[0m=foo.go:18 \x1b[36mmessage\x1b[0m=hi \x1b[36mapp\x1b[0m=api \x1b[36mcount\x1b[0m=1\n",
+		},
+		{
+			name: "development without colors",
+			cfg:  Config{Config: encode.ProConsoleConfig, Mode: mode.Development, Colors: NeverColors},
+			want: "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hi app=api count=1\n",
+		},
+		{
+			name: "theme",
+			cfg:  Config{Config: encode.ProConsoleConfig, Mode: mode.Production, Colors: AlwaysColors, Theme: theme},
+			want: "INFO 2020-03-22T13:42:12.000Z \x1b[38;2;0;128;255mcaller\x1b[0m=foo.go:18 \x1b[38;2;0;128;255mmessage\x1b[0m=hi \x1b[38;2;0;128;255mapp\x1b[0m=api \x1b[38;2;0;128;255mcount\x1b[0m=1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := NewEncoder(tt.cfg)
+			zap.String("app", "api").AddTo(enc)
+
+			ent := info_entry
+			ent.Message = "hi"
+			ent.Stack = ""
+			got, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Int("count", 1)})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestEncoder_EncodeEntry_contextEscapes(t *testing.T) {
+	// escape sequences in the values of a context are not taken for keys
+	enc := NewEncoder(dev_cfg)
+	zap.String("bold", "\x1b[1mtext").AddTo(enc)
+	zap.String("trailing", "end\x1b").AddTo(enc)
+
+	got, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.ErrorLevel}, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, got.String(), " \x1b[31mbold\x1b[0m=\x1b[1mtext \x1b[31mtrailing\x1b[0m=end\x1b\n")
+}
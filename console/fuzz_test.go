@@ -0,0 +1,72 @@
+//go:build go1.18
+// +build go1.18
+
+package console
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/syllabix/logger/encode"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func FuzzEncoder_EncodeEntry(f *testing.F) {
+	f.Add("key", "value", "message", true, false, false)
+	f.Add("\x1b[31mkey", "\x1b[1;38;5;208mvalue\x1b[0m", "\x1b", true, true, true)
+	f.Add("\x1b[", "trailing\x1b", "\x1b[31", false, true, false)
+	f.Add("", "a, b", "{[x]}", true, false, true)
+	f.Fuzz(func(t *testing.T, key, val, msg string, escape, pretty, bracketed bool) {
+		style := DottedNamespaces
+		if bracketed {
+			style = BracketedNamespaces
+		}
+
+		encodeEntry := func(colors Colors) string {
+			enc := NewEncoder(Config{
+				Config:     encode.ProConsoleConfig,
+				Mode:       mode.Development,
+				Escape:     escape,
+				Namespaces: style,
+				Pretty:     pretty,
+				Colors:     colors,
+			})
+			zap.String(key, val).AddTo(enc)
+			zap.Namespace(key).AddTo(enc)
+			ctx := enc.Clone()
+			zap.Strings(key, []string{val, msg}).AddTo(ctx)
+
+			ent := zapcore.Entry{Level: zapcore.WarnLevel, Message: msg, Stack: stack}
+			buf, err := ctx.EncodeEntry(ent, []zapcore.Field{
+				zap.String(key, val),
+				zap.Error(fmt.Errorf("%s: %w", msg, errors.New(val))),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return buf.String()
+		}
+
+		colored := encodeEntry(AlwaysColors)
+		plain := encodeEntry(NeverColors)
+		if !escape {
+			return
+		}
+
+		// escaped values hold no escape sequences, so the colors are all
+		// the encoder adds
+		if strings.ContainsRune(plain, '\x1b') {
+			t.Fatalf("escape sequence in uncolored output %q", plain)
+		}
+		if stripped := encode.StripANSI(colored); stripped != plain {
+			t.Fatalf("colored output %q is not the uncolored output %q once stripped", colored, plain)
+		}
+		if !pretty && strings.IndexByte(plain, '\n') != len(plain)-1 {
+			t.Fatalf("escaped entry %q is not a single line", plain)
+		}
+	})
+}
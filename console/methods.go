@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/syllabix/logger/encode"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -16,7 +17,7 @@ func (e *Encoder) AppendBool(val bool) {
 }
 
 func (e *Encoder) AppendByteString(bstr []byte) {
-	e.buf.AppendString(string(bstr))
+	e.AppendString(string(bstr))
 }
 
 func (e *Encoder) AppendComplex128(val complex128) {
@@ -58,6 +59,10 @@ func (e *Encoder) AppendInt8(val int8) {
 }
 
 func (e *Encoder) AppendString(str string) {
+	if e.escape && !e.trusted {
+		appendEscaped(e.buf, str, e.nested())
+		return
+	}
 	e.buf.AppendString(str)
 }
 
@@ -87,12 +92,17 @@ func (e *Encoder) AppendUintptr(val uintptr) {
 
 func (e *Encoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
 	e.addKey(key)
-	return marshaler.MarshalLogArray(e)
+	return e.AppendArray(marshaler)
 }
 
 func (e *Encoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
 	e.addKey(key)
-	return obj.MarshalLogObject(e)
+	// a trace is written compactly as trace=<trace id>/<span id>
+	if t, ok := obj.(encode.Trace); ok {
+		e.AppendString(t.String())
+		return nil
+	}
+	return e.AppendObject(obj)
 }
 
 func (e *Encoder) AddBinary(key string, val []byte) {
@@ -199,8 +209,26 @@ func (e *Encoder) AddReflected(key string, val interface{}) error {
 }
 
 func (e *Encoder) OpenNamespace(key string) {
-	// no-op -
-	// namespaces do not really visually apply to console logs
+	// in an object, a namespace is a nested object that ends with it
+	if e.depth > 0 {
+		e.addKey(key)
+		e.open('{')
+		e.braces++
+		return
+	}
+
+	if !e.ns.open(key) {
+		return
+	}
+	if e.ns.style == BracketedNamespaces {
+		e.separate()
+		if e.escape {
+			key = escapeKey(key)
+		}
+		e.appendKey(key)
+		e.buf.AppendByte('[')
+		e.ns.bracketOpened = true
+	}
 }
 
 func (e *Encoder) AppendDuration(val time.Duration) {
@@ -220,16 +248,23 @@ func (e *Encoder) AppendTime(val time.Time) {
 }
 
 func (e *Encoder) AppendArray(arr zapcore.ArrayMarshaler) error {
-	e.buf.AppendByte('[')
-	err := arr.MarshalLogArray(e)
-	e.buf.AppendByte(']')
+	e.open('[')
+	err := arr.MarshalLogArray(arrayEncoder{e})
+	e.close(']')
 	return err
 }
 
 func (e *Encoder) AppendObject(obj zapcore.ObjectMarshaler) error {
-	e.buf.AppendByte('{')
+	braces := e.braces
+	e.braces = 0
+	e.open('{')
 	err := obj.MarshalLogObject(e)
-	e.buf.AppendByte('}')
+	// close the namespaces opened in the object
+	for ; e.braces > 0; e.braces-- {
+		e.close('}')
+	}
+	e.close('}')
+	e.braces = braces
 	return err
 }
 
@@ -0,0 +1,94 @@
+package console
+
+import "strings"
+
+// namespaces tracks the namespaces opened on an encoder
+type namespaces struct {
+	style    NamespaceStyle
+	hideRoot bool
+
+	// names are the open namespaces, outermost first
+	names []string
+	// rootHidden is set when names[0] is the hidden root namespace
+	rootHidden bool
+	// prefix is the dotted path of the visible namespaces, with a trailing dot
+	prefix string
+	// bracketOpened is set right after a bracket is written, so the first
+	// field of a bracketed namespace is not preceded by a space
+	bracketOpened bool
+}
+
+func (n namespaces) clone() namespaces {
+	n.names = append([]string(nil), n.names...)
+	return n
+}
+
+// visible returns the open namespaces that are rendered
+func (n *namespaces) visible() []string {
+	if n.rootHidden {
+		return n.names[1:]
+	}
+	return n.names
+}
+
+// open records a namespace, and reports whether it is rendered
+func (n *namespaces) open(key string) bool {
+	n.names = append(n.names, key)
+	if len(n.names) == 1 && n.hideRoot {
+		n.rootHidden = true
+		return false
+	}
+	n.prefix = n.prefix + key + "."
+	return true
+}
+
+// truncate closes the namespaces opened after the first depth, and returns
+// the number of rendered namespaces that were closed
+func (n *namespaces) truncate(depth int) int {
+	if depth >= len(n.names) {
+		return 0
+	}
+
+	before := len(n.visible())
+	n.names = n.names[:depth]
+	if len(n.names) == 0 {
+		n.rootHidden = false
+	}
+	closed := before - len(n.visible())
+
+	visible := n.visible()
+	if len(visible) == 0 {
+		n.prefix = ""
+	} else {
+		n.prefix = strings.Join(visible, ".") + "."
+	}
+	return closed
+}
+
+// separate writes the space ahead of a field, unless it is the first field of
+// a bracketed namespace
+func (e *Encoder) separate() {
+	if e.ns.bracketOpened {
+		e.ns.bracketOpened = false
+		return
+	}
+	e.buf.AppendByte(' ')
+}
+
+// nested reports whether values are written inside an array, an object or a
+// bracketed namespace, where brackets and separators in them must be quoted
+func (e *Encoder) nested() bool {
+	return e.depth > 0 || e.ns.style == BracketedNamespaces && len(e.ns.visible()) > 0
+}
+
+// closeNamespaces closes the namespaces opened after the first depth
+func (e *Encoder) closeNamespaces(depth int) {
+	closed := e.ns.truncate(depth)
+	if e.ns.style != BracketedNamespaces {
+		return
+	}
+	for i := 0; i < closed; i++ {
+		e.buf.AppendByte(']')
+	}
+	e.ns.bracketOpened = false
+}
@@ -0,0 +1,141 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/encode"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// request is an object that opens a namespace of its own
+type request struct{}
+
+func (request) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("method", "GET")
+	enc.OpenNamespace("headers")
+	enc.AddString("accept", "json")
+	return nil
+}
+
+func TestEncoder_namespaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    NamespaceStyle
+		hideRoot bool
+		escape   bool
+		mode     mode.Kind
+		context  []zapcore.Field
+		fields   []zapcore.Field
+		want     string
+	}{
+		{
+			name:    "dotted",
+			context: []zapcore.Field{zap.String("app", "api"), zap.Namespace("http"), zap.Int("status", 200)},
+			fields:  []zapcore.Field{zap.Namespace("db"), zap.Int("status", 1)},
+			want:    "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello app=api http.status=200 http.db.status=1\n",
+		},
+		{
+			name:     "dotted hiding the root",
+			hideRoot: true,
+			context:  []zapcore.Field{zap.String("@source_host", "box"), zap.Namespace("@fields"), zap.String("app", "api")},
+			fields:   []zapcore.Field{zap.Namespace("http"), zap.Int("status", 200)},
+			want:     "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello @source_host=box app=api http.status=200\n",
+		},
+		{
+			name:    "dotted showing the root",
+			context: []zapcore.Field{zap.Namespace("@fields"), zap.String("app", "api")},
+			want:    "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello @fields.app=api\n",
+		},
+		{
+			name:    "bracketed",
+			style:   BracketedNamespaces,
+			context: []zapcore.Field{zap.String("app", "api"), zap.Namespace("http"), zap.String("method", "GET")},
+			fields:  []zapcore.Field{zap.Int("status", 200), zap.Namespace("db"), zap.Int("rows", 3)},
+			want:    "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello app=api http[method=GET status=200 db[rows=3]]\n",
+		},
+		{
+			name:    "bracketed escaping values",
+			style:   BracketedNamespaces,
+			escape:  true,
+			context: []zapcore.Field{zap.String("app", "a]"), zap.Namespace("http")},
+			fields:  []zapcore.Field{zap.String("v", "a]"), zap.String("w", "x,y")},
+			want:    "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello app=a] http[v=\"a]\" w=\"x,y\"]\n",
+		},
+		{
+			name:     "bracketed hiding the root",
+			style:    BracketedNamespaces,
+			hideRoot: true,
+			context:  []zapcore.Field{zap.Namespace("@fields"), zap.String("app", "api")},
+			fields:   []zapcore.Field{zap.Namespace("http")},
+			want:     "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello app=api http[]\n",
+		},
+		{
+			name:     "namespaces in objects end with them",
+			hideRoot: true,
+			fields:   []zapcore.Field{zap.Object("req", request{}), zap.String("after", "yes")},
+			want:     "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello req={method=GET, headers={accept=json}} after=yes\n",
+		},
+		{
+			name:   "bracketed namespaces in objects end with them",
+			style:  BracketedNamespaces,
+			fields: []zapcore.Field{zap.Object("req", request{}), zap.String("after", "yes")},
+			want:   "INFO 2020-03-22T13:42:12.000Z caller=foo.go:18 message=hello req={method=GET, headers={accept=json}} after=yes\n",
+		},
+		{
+			name:    "dev mode colors",
+			style:   BracketedNamespaces,
+			mode:    mode.Development,
+			context: []zapcore.Field{zap.Namespace("http"), zap.Int("status", 200)},
+			want:    "\x1b[36mINFO\x1b[0m 2020-03-22T13:42:12.000Z \x1b[36mcaller\x1b[0m=foo.go:18 \x1b[36mmessage\x1b[0m=hello \x1b[36mhttp\x1b[0m[\x1b[36mstatus\x1b[0m=200]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.mode
+			cfg := *encode.ProConsoleConfig
+			if m == mode.Development {
+				cfg = *encode.DevConsoleConfig
+			} else {
+				m = mode.Production
+			}
+			enc := NewEncoder(Config{Config: &cfg, Mode: m, Namespaces: tt.style, HideRoot: tt.hideRoot, Escape: tt.escape})
+			for _, f := range tt.context {
+				f.AddTo(enc)
+			}
+
+			ent := info_entry
+			ent.Message = "hello"
+			ent.Stack = ""
+			got, err := enc.Clone().EncodeEntry(ent, tt.fields)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestEncoder_namespacesAcrossClone(t *testing.T) {
+	enc := NewEncoder(Config{Config: encode.ProConsoleConfig, Mode: mode.Production, HideRoot: true})
+	zap.Namespace("@fields").AddTo(enc)
+	zap.Namespace("http").AddTo(enc)
+
+	// a With context opening another namespace does not affect its parent
+	child := enc.Clone()
+	zap.Namespace("db").AddTo(child)
+
+	ent := zapcore.Entry{Message: "hello"}
+	got, err := child.EncodeEntry(ent, []zapcore.Field{zap.Int("status", 1)})
+	assert.NoError(t, err)
+	assert.Contains(t, got.String(), " http.db.status=1\n")
+
+	got, err = enc.EncodeEntry(ent, []zapcore.Field{zap.Int("status", 200)})
+	assert.NoError(t, err)
+	assert.Contains(t, got.String(), " http.status=200\n")
+
+	// entries leave the namespaces of the encoder they were encoded with open
+	got, err = enc.EncodeEntry(ent, []zapcore.Field{zap.Int("status", 404)})
+	assert.NoError(t, err)
+	assert.Contains(t, got.String(), " http.status=404\n")
+}
@@ -24,6 +24,17 @@ func put(enc *Encoder) {
 	enc.config = nil
 	enc.buf = nil
 	enc.mode = mode.None
+	enc.escape = false
+	enc.colors = ModeColors
+	enc.theme = nil
+	enc.pretty = false
+	enc.module = ""
+	enc.trusted = false
+	enc.ns = namespaces{}
+	enc.depth = 0
+	enc.sep = false
+	enc.braces = 0
+	enc.keys = nil
 	enc.level = zap.InfoLevel
 	pool.Put(enc)
 }
@@ -0,0 +1,130 @@
+package console
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/syllabix/logger/encode"
+	"go.uber.org/zap/zapcore"
+)
+
+const indent = "    "
+
+// wrapping returns the error of a field when it wraps other errors, so its
+// chain can be written below the entry, and nil otherwise
+func wrapping(f zapcore.Field) error {
+	if f.Type != zapcore.ErrorType {
+		return nil
+	}
+	err, ok := f.Interface.(error)
+	if !ok || errors.Unwrap(err) == nil {
+		return nil
+	}
+	return err
+}
+
+// writeCauses writes an error indented below the entry, followed by each
+// error it wraps on a caused by line of its own
+func (e *Encoder) writeCauses(key string, err error, lineEnding string) {
+	e.buf.AppendString(indent)
+	if e.escape {
+		key = escapeKey(key)
+	}
+	e.buf.AppendString(key)
+	e.buf.AppendString(": ")
+	e.appendMessage(err.Error())
+	e.buf.AppendString(lineEnding)
+
+	for err = errors.Unwrap(err); err != nil; err = errors.Unwrap(err) {
+		e.buf.AppendString(indent + indent)
+		e.paint(e.styles().Faint, "caused by:")
+		e.buf.AppendByte(' ')
+		e.appendMessage(err.Error())
+		e.buf.AppendString(lineEnding)
+	}
+}
+
+// appendMessage writes an error message, which is quoted when it does not fit
+// on a single line or the encoder escapes values
+func (e *Encoder) appendMessage(msg string) {
+	if e.escape || strings.ContainsAny(msg, "\r\n") {
+		appendEscaped(e.buf, msg, false)
+		return
+	}
+	e.buf.AppendString(msg)
+}
+
+// writeStack writes a stacktrace as captured by zap indented below the entry,
+// one frame per line. Frames of the module are highlighted, and frames of the
+// runtime and standard library dimmed
+func (e *Encoder) writeStack(stack string, lineEnding string) {
+	lines := strings.Split(stack, "\n")
+	for i := 0; i < len(lines); i += 2 {
+		function := lines[i]
+		if isEmpty(function) {
+			continue
+		}
+
+		var location string
+		if i+1 < len(lines) {
+			location = strings.TrimSpace(lines[i+1])
+		}
+
+		frame := "at " + function
+		if !isEmpty(location) {
+			frame += " (" + location + ")"
+		}
+
+		e.buf.AppendString(indent)
+		switch {
+		case e.inModule(function):
+			e.paint(e.styles().Highlight, frame)
+		case isStandard(function):
+			e.paint(e.styles().Faint, frame)
+		default:
+			e.buf.AppendString(frame)
+		}
+		e.buf.AppendString(lineEnding)
+	}
+}
+
+// paint writes s in the given style when the encoder colors its output
+func (e *Encoder) paint(style encode.Style, s string) {
+	if !e.colored() {
+		e.buf.AppendString(s)
+		return
+	}
+	e.buf.AppendString(style.Add(s))
+}
+
+// inModule reports whether function belongs to the module of the encoder, or
+// to the main package
+func (e *Encoder) inModule(function string) bool {
+	if packageOf(function) == "main" {
+		return true
+	}
+	if isEmpty(e.module) {
+		return false
+	}
+	return strings.HasPrefix(function, e.module+".") || strings.HasPrefix(function, e.module+"/")
+}
+
+// isStandard reports whether function belongs to the runtime or the standard
+// library, whose import paths do not start with a domain
+func isStandard(function string) bool {
+	pkg := packageOf(function)
+	if i := strings.IndexByte(pkg, '/'); i >= 0 {
+		pkg = pkg[:i]
+	}
+	return !strings.Contains(pkg, ".")
+}
+
+// packageOf returns the import path of the package of a function, as in
+// net/http for net/http.(*conn).serve
+func packageOf(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
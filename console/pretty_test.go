@@ -0,0 +1,107 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/encode"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const stack = "github.com/acme/app/server.(*Server).handle\n" +
+	"\t/src/app/server/server.go:42\n" +
+	"github.com/gorilla/mux.(*Router).ServeHTTP\n" +
+	"\t/go/pkg/mod/github.com/gorilla/mux/mux.go:210\n" +
+	"net/http.(*conn).serve\n" +
+	"\t/usr/local/go/src/net/http/server.go:1925"
+
+func TestEncoder_EncodeEntry_pretty(t *testing.T) {
+	inner := errors.New("no such file")
+	wrapped := fmt.Errorf("read config: %w", fmt.Errorf("open app.yml: %w", inner))
+
+	tests := []struct {
+		name   string
+		mode   mode.Kind
+		fields []zapcore.Field
+		stack  string
+		want   string
+	}{
+		{
+			name:  "stacktrace below the entry",
+			mode:  mode.Development,
+			stack: stack,
+			want: "\x1b[31mERROR\x1b[0m 2020-03-22T13:42:12.000Z \x1b[31mmessage\x1b[0m=failed\n" +
+				"    \x1b[1mat github.com/acme/app/server.(*Server).handle (/src/app/server/server.go:42)\x1b[0m\n" +
+				"    at github.com/gorilla/mux.(*Router).ServeHTTP (/go/pkg/mod/github.com/gorilla/mux/mux.go:210)\n" +
+				"    \x1b[2mat net/http.(*conn).serve (/usr/local/go/src/net/http/server.go:1925)\x1b[0m\n",
+		},
+		{
+			name:   "error chain below the entry",
+			mode:   mode.Development,
+			fields: []zapcore.Field{zap.Error(wrapped), zap.NamedError("plain", inner)},
+			want: "\x1b[31mERROR\x1b[0m 2020-03-22T13:42:12.000Z \x1b[31mmessage\x1b[0m=failed \x1b[31mplain\x1b[0m=no such file\n" +
+				"    error: read config: open app.yml: no such file\n" +
+				"        \x1b[2mcaused by:\x1b[0m open app.yml: no such file\n" +
+				"        \x1b[2mcaused by:\x1b[0m no such file\n",
+		},
+		{
+			name:   "production keeps a single line",
+			mode:   mode.Production,
+			fields: []zapcore.Field{zap.Error(wrapped)},
+			stack:  "main.main\n\t/src/main.go:8",
+			want:   "ERROR 2020-03-22T13:42:12.000Z message=failed error=read config: open app.yml: no such file stacktrace=main.main\n\t/src/main.go:8\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *encode.ProConsoleConfig
+			if tt.mode == mode.Development {
+				cfg = *encode.DevConsoleConfig
+			}
+			cfg.CallerKey = ""
+			enc := NewEncoder(Config{Config: &cfg, Mode: tt.mode, Pretty: true, Module: "github.com/acme/app"})
+
+			ent := zapcore.Entry{Level: zapcore.ErrorLevel, Time: info_entry.Time, Message: "failed", Stack: tt.stack}
+			got, err := enc.EncodeEntry(ent, tt.fields)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestEncoder_inModule(t *testing.T) {
+	enc := NewEncoder(Config{Module: "github.com/acme/app"})
+	tests := []struct {
+		function string
+		want     bool
+	}{
+		{function: "github.com/acme/app.Run", want: true},
+		{function: "github.com/acme/app/server.(*Server).handle", want: true},
+		{function: "github.com/acme/application.Run", want: false},
+		{function: "main.main", want: true},
+		{function: "runtime.main", want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, enc.inModule(tt.function), tt.function)
+	}
+}
+
+func Test_isStandard(t *testing.T) {
+	tests := []struct {
+		function string
+		want     bool
+	}{
+		{function: "runtime.goexit", want: true},
+		{function: "net/http.(*conn).serve", want: true},
+		{function: "net/http.HandlerFunc.ServeHTTP", want: true},
+		{function: "go.uber.org/zap.(*Logger).Error", want: false},
+		{function: "github.com/gorilla/mux.(*Router).ServeHTTP", want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isStandard(tt.function), tt.function)
+	}
+}
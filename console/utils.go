@@ -1,28 +1,32 @@
 package console
 
 import (
+	"strings"
+
 	"github.com/syllabix/logger/encode"
-	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/buffer"
 )
 
 func isEmpty(str string) bool {
 	return len(str) < 1
 }
 
-func recolor(buffer []byte, lvl zapcore.Level) {
-	i := 0
-	for i < len(buffer) {
-		b1 := buffer[i]
-		if b1 == '\x1b' {
-			i++
-			b2 := buffer[i]
-			if b2 == '[' {
-				if buffer[i+1] != '0' {
-					buffer[i+1], buffer[i+2] = encode.ColorBytesForLevel(lvl)
-					i = i + 2
-				}
-			}
-		}
-		i++
+// appendEscaped writes a value supplied by the user of a logger, without
+// its ANSI escape sequences, and quoted when needed. Values in arrays and
+// objects are also quoted when they contain separators or brackets
+func appendEscaped(buf *buffer.Buffer, str string, nested bool) {
+	str = encode.StripANSI(str)
+	if isEmpty(str) || encode.NeedsQuoting(str) || nested && strings.ContainsAny(str, ",[]{}") {
+		encode.AppendQuoted(buf, str)
+		return
 	}
+	buf.AppendString(str)
+}
+
+// escapeKey replaces the characters of key that are not allowed in keys
+func escapeKey(key string) string {
+	buf := bufferpool.Get()
+	defer buf.Free()
+	encode.AppendKey(buf, encode.StripANSI(key))
+	return buf.String()
 }
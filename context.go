@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/syllabix/logger/internal/registry"
+	"go.uber.org/zap"
+)
+
+// contextKey is the key of the fields stored in a context
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying the provided fields, in addition
+// to those already added to it, such as a request or user ID. Loggers returned
+// by FromContext for the new context include all of them
+func WithContext(ctx context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	existing := contextFields(ctx)
+	combined := make([]zap.Field, 0, len(existing)+len(fields))
+	combined = append(combined, existing...)
+	combined = append(combined, fields...)
+	return context.WithValue(ctx, contextKey{}, combined)
+}
+
+// FromContext returns the logger of the calling package, like New, with the
+// fields added to ctx by WithContext, and the trace_id and span_id of the trace
+// ctx belongs to, as found by the TraceFrom extractor. When ctx carries neither
+// the plain logger of the package is returned
+func FromContext(ctx context.Context) *zap.Logger {
+	log := packageLogger(pkgname())
+
+	fields := contextFields(ctx)
+	if trace := traceFields(ctx); len(trace) > 0 {
+		fields = append(trace, fields...)
+	}
+	if len(fields) > 0 {
+		return log.With(fields...)
+	}
+	return log
+}
+
+// contextFields returns the fields added to ctx by WithContext
+func contextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextKey{}).([]zap.Field)
+	return fields
+}
+
+// cached is the logger of a package along with the level it was created with
+type cached struct {
+	level zap.AtomicLevel
+	log   *zap.Logger
+}
+
+// loggers caches the logger of every package FromContext was called in. As
+// loggers follow Configure, a cached logger only goes stale when the package
+// is removed from the registry
+var loggers sync.Map // registry.Package -> cached
+
+func packageLogger(pkg registry.Package) *zap.Logger {
+	level := registry.Get(pkg)
+	if c, ok := loggers.Load(pkg); ok && c.(cached).level == level {
+		return c.(cached).log
+	}
+
+	log := newLogger(pkg)
+	loggers.Store(pkg, cached{level: level, log: log})
+	return log
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+)
+
+func TestFromContext(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	out := new(bytes.Buffer)
+	Configure(ConsoleWriter(out), Mode(mode.Production))
+
+	FromContext(context.Background()).Info("plain")
+	assert.Contains(t, out.String(), "message=plain @source_host=")
+	assert.NotContains(t, out.String(), "request_id")
+
+	out.Reset()
+	ctx := WithContext(context.Background(), zap.String("request_id", "abc"))
+	ctx = WithContext(ctx, zap.Int("user_id", 42))
+	FromContext(ctx).Info("scoped")
+	assert.Contains(t, out.String(), "message=scoped")
+	assert.Contains(t, out.String(), "request_id=abc user_id=42")
+	assert.Contains(t, out.String(), "caller=logger/context_test.go")
+
+	// loggers from a context follow the level of the calling package, also
+	// when FromContext is called in a closure
+	out.Reset()
+	assert.NoError(t, SetLevelForPackage("github.com/syllabix/logger", zap.WarnLevel))
+	defer ClearLevelForPackage("github.com/syllabix/logger")
+	func() {
+		FromContext(ctx).Info("filtered")
+	}()
+	assert.Empty(t, out.String())
+}
+
+func TestWithContext(t *testing.T) {
+	assert.Nil(t, contextFields(context.Background()))
+	assert.Nil(t, contextFields(nil))
+
+	parent := WithContext(context.Background(), zap.String("request_id", "abc"))
+	assert.Equal(t, parent, WithContext(parent))
+
+	first := WithContext(parent, zap.Int("user_id", 1))
+	second := WithContext(parent, zap.Int("user_id", 2))
+	assert.Equal(t, []zap.Field{zap.String("request_id", "abc")}, contextFields(parent))
+	assert.Equal(t, []zap.Field{zap.String("request_id", "abc"), zap.Int("user_id", 1)}, contextFields(first))
+	assert.Equal(t, []zap.Field{zap.String("request_id", "abc"), zap.Int("user_id", 2)}, contextFields(second))
+}
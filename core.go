@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/syllabix/logger/console"
+	"github.com/syllabix/logger/encode"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/json"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// generation is the core built from one version of the global config. Every
+// call to Configure replaces the current generation as a whole, so loggers
+// never observe a partially applied configuration
+type generation struct {
+	// core is the shared core with the base fields applied
+	core zapcore.Core
+	// sinks and filtered are the cores of the sinks, without base fields
+	sinks    []sinkCore
+	filtered []filteredCore
+
+	samplers     samplers
+	extractTrace TraceExtractor
+}
+
+// forPackage returns the core for loggers in pkg, which includes the sinks
+// restricted to packages that pkg is part of. A trace is added ahead of the
+// base fields, so the json encoder writes it outside of the @fields namespace
+func (g *generation) forPackage(pkg registry.Package, trace *encode.Trace) zapcore.Core {
+	sinks := g.sinks
+	for _, f := range g.filtered {
+		if f.matches(pkg) {
+			if len(sinks) == len(g.sinks) {
+				sinks = append([]sinkCore(nil), g.sinks...)
+			}
+			sinks = append(sinks, f.sinkCore)
+		}
+	}
+	if len(sinks) == len(g.sinks) && trace == nil {
+		return g.core
+	}
+
+	var prefix []zapcore.Field
+	if trace != nil {
+		prefix = []zapcore.Field{encode.TraceField(*trace)}
+	}
+	return teeSinks(sinks, prefix)
+}
+
+// sinkCore is the core of a sink along with the base fields its encoder
+// starts every entry with
+type sinkCore struct {
+	core   zapcore.Core
+	fields []zapcore.Field
+}
+
+// baseFielder is implemented by encoders that lay out the base fields of
+// their entries themselves, such as the json encoder
+type baseFielder interface {
+	BaseFields(host, application string) []zapcore.Field
+}
+
+// newSinkCore returns the core of a sink writing entries encoded by enc to w
+func newSinkCore(enc zapcore.Encoder, w io.Writer, level zapcore.LevelEnabler) sinkCore {
+	var fields []zapcore.Field
+	if b, ok := enc.(baseFielder); ok {
+		fields = b.BaseFields(hostname(), global.appname)
+	} else {
+		fields = json.Legacy.Fields(hostname(), global.appname)
+	}
+	return sinkCore{
+		core:   zapcore.NewCore(enc, zapcore.AddSync(w), level),
+		fields: fields,
+	}
+}
+
+// with returns the core with prefix and the base fields applied
+func (s sinkCore) with(prefix []zapcore.Field) zapcore.Core {
+	if len(prefix) == 0 {
+		return s.core.With(s.fields)
+	}
+	fields := make([]zapcore.Field, 0, len(prefix)+len(s.fields))
+	fields = append(fields, prefix...)
+	fields = append(fields, s.fields...)
+	return s.core.With(fields)
+}
+
+// teeSinks returns a core writing to all sinks, with prefix and their base
+// fields applied
+func teeSinks(sinks []sinkCore, prefix []zapcore.Field) zapcore.Core {
+	cores := make([]zapcore.Core, len(sinks))
+	for i, s := range sinks {
+		cores[i] = s.with(prefix)
+	}
+	return zapcore.NewTee(cores...)
+}
+
+var (
+	current     atomic.Value // *generation
+	configMutex sync.Mutex
+)
+
+func init() {
+	current.Store(build())
+}
+
+func loadGeneration() *generation {
+	return current.Load().(*generation)
+}
+
+// build constructs the core shared by all logger instances from the global
+// config. Level filtering and sampling are left to the cores returned by
+// newCore, which apply those of the package a logger was created in
+func build() *generation {
+	all := zap.LevelEnablerFunc(func(zapcore.Level) bool { return true })
+	sinks := make([]sinkCore, 0, 2+len(global.sinks))
+
+	if global.csink != nil {
+		var cEncoder zapcore.Encoder
+		if global.cformat != nil {
+			cEncoder = global.cformat()
+		} else {
+			cEncoder = console.NewEncoder(consoleConfig(global.csink))
+		}
+		sinks = append(sinks, newSinkCore(cEncoder, global.csink, all))
+	}
+
+	// if a json sink has been set, configure it
+	// with the json Encoder of the configured schema,
+	// and Tee the console encoder with it
+	if global.jsink != nil {
+		jEncoder := jsonEncoder()
+		sinks = append(sinks, newSinkCore(jEncoder, global.jsink, all))
+	}
+
+	named, filtered := buildSinks()
+	sinks = append(sinks, named...)
+
+	extractTrace := global.traceExtractor
+	if extractTrace == nil {
+		extractTrace = traceparentFromContext
+	}
+
+	return &generation{
+		core:         teeSinks(sinks, nil),
+		sinks:        sinks,
+		filtered:     filtered,
+		samplers:     newSamplers(global),
+		extractTrace: extractTrace,
+	}
+}
+
+// derived caches the core of a generation with the fields of a logger applied,
+// along with the sampler of the logger's package
+type derived struct {
+	gen     *generation
+	core    zapcore.Core
+	sampler *sampler
+}
+
+// reconfigurableCore is the zapcore.Core behind every logger returned by New. It
+// delegates to the current generation, re-applying the logger's own context
+// fields whenever Configure has replaced it
+type reconfigurableCore struct {
+	zapcore.LevelEnabler
+	pkg    registry.Package
+	trace  *encode.Trace
+	fields []zapcore.Field
+	cache  atomic.Value // *derived
+}
+
+func newCore(pkg registry.Package, level zapcore.LevelEnabler) *reconfigurableCore {
+	return &reconfigurableCore{LevelEnabler: level, pkg: pkg}
+}
+
+func (c *reconfigurableCore) current() *derived {
+	gen := loadGeneration()
+	if d, ok := c.cache.Load().(*derived); ok && d.gen == gen {
+		return d
+	}
+
+	core := gen.forPackage(c.pkg, c.trace)
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+	d := &derived{
+		gen:     gen,
+		core:    core,
+		sampler: gen.samplers.forPackage(c.pkg),
+	}
+	c.cache.Store(d)
+	return d
+}
+
+// With implements the With method of the zapcore Core interface
+func (c *reconfigurableCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := newCore(c.pkg, c.LevelEnabler)
+	clone.trace = c.trace
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	for _, f := range fields {
+		if t, ok := encode.TraceOf(f); ok {
+			clone.trace = &t
+			continue
+		}
+		clone.fields = append(clone.fields, f)
+	}
+	return clone
+}
+
+// Check implements the Check method of the zapcore Core interface
+func (c *reconfigurableCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	d := c.current()
+	if d.sampler != nil && !d.sampler.sample(ent) {
+		return ce
+	}
+	return d.core.Check(ent, ce)
+}
+
+// Write implements the Write method of the zapcore Core interface
+func (c *reconfigurableCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().core.Write(ent, fields)
+}
+
+// Sync implements the Sync method of the zapcore Core interface
+func (c *reconfigurableCore) Sync() error {
+	return c.current().core.Sync()
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/encode"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+)
+
+func TestReconfigurableCore(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	first := new(bytes.Buffer)
+	second := new(bytes.Buffer)
+	jsonw := new(bytes.Buffer)
+
+	Configure(
+		AppName("first-app"),
+		ConsoleWriter(first),
+		Mode(mode.Production),
+		Level(zap.InfoLevel),
+	)
+
+	log := New().With(zap.String("request", "abc"))
+	log.Debug("not enabled")
+	log.Info("before")
+	assert.Contains(t, first.String(), "message=before")
+	assert.Contains(t, first.String(), "application=first-app request=abc")
+	assert.NotContains(t, first.String(), "not enabled")
+
+	Configure(
+		AppName("second-app"),
+		ConsoleWriter(second),
+		JSONWriter(jsonw),
+		Mode(mode.Development),
+		ConsoleColors(encode.ColorAlways),
+		Level(zap.DebugLevel),
+	)
+
+	first.Reset()
+	log.Debug("after")
+	assert.Empty(t, first.String())
+	assert.Contains(t, second.String(), "\x1b[35mmessage\x1b[0m=after")
+	assert.Contains(t, second.String(), "\x1b[35mapplication\x1b[0m=second-app \x1b[35mrequest\x1b[0m=abc")
+	assert.Contains(t, jsonw.String(), `"@fields":{"application":"second-app","request":"abc",`)
+}
+
+func TestReconfigurableCore_Sync(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	Configure(ConsoleWriter(nil), JSONWriter(nil))
+	log := New()
+	log.Info("goes nowhere")
+	assert.NoError(t, log.Sync())
+}
@@ -0,0 +1,84 @@
+package encode
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys of the special fields recognized by Google Cloud Logging in structured
+// json written to stdout
+const (
+	// GCPSourceLocationKey is the key of the source location of an entry
+	GCPSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	// GCPTraceKey is the key of the trace an entry was logged in
+	GCPTraceKey = "logging.googleapis.com/trace"
+	// GCPSpanIDKey is the key of the span an entry was logged in
+	GCPSpanIDKey = "logging.googleapis.com/spanId"
+	// GCPLabelsKey is the key of the labels of an entry
+	GCPLabelsKey = "logging.googleapis.com/labels"
+)
+
+// GCPConfig is a json encoder config for Google Cloud Logging. The source
+// location is written by the json encoder, rather than as a caller field
+var GCPConfig = zapcore.EncoderConfig{
+	MessageKey:     "message",
+	LevelKey:       "severity",
+	EncodeLevel:    GCPSeverityEncoder,
+	TimeKey:        "time",
+	EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+	NameKey:        "logger",
+	EncodeDuration: zapcore.SecondsDurationEncoder,
+	StacktraceKey:  "stack_trace",
+}
+
+// CloudWatchConfig is a json encoder config for AWS CloudWatch Logs, using the
+// keys of the Lambda json log format
+var CloudWatchConfig = zapcore.EncoderConfig{
+	MessageKey:     "message",
+	LevelKey:       "level",
+	EncodeLevel:    CloudWatchLevelEncoder,
+	TimeKey:        "timestamp",
+	EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+	NameKey:        "logger",
+	CallerKey:      "caller",
+	EncodeCaller:   zapcore.ShortCallerEncoder,
+	EncodeDuration: zapcore.SecondsDurationEncoder,
+	StacktraceKey:  "stackTrace",
+}
+
+// GCPSeverity returns the Google Cloud Logging severity of a level
+func GCPSeverity(l zapcore.Level) string {
+	switch l {
+	case zapcore.DebugLevel:
+		return "DEBUG"
+	case zapcore.InfoLevel:
+		return "INFO"
+	case zapcore.WarnLevel:
+		return "WARNING"
+	case zapcore.ErrorLevel:
+		return "ERROR"
+	case zapcore.DPanicLevel:
+		return "CRITICAL"
+	case zapcore.PanicLevel:
+		return "ALERT"
+	case zapcore.FatalLevel:
+		return "EMERGENCY"
+	default:
+		return "DEFAULT"
+	}
+}
+
+// GCPSeverityEncoder encodes a level as a Google Cloud Logging severity
+func GCPSeverityEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(GCPSeverity(l))
+}
+
+// CloudWatchLevelEncoder encodes a level with the names of the Lambda json log
+// format, in which the panic levels are FATAL
+func CloudWatchLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch l {
+	case zapcore.DPanicLevel, zapcore.PanicLevel, zapcore.FatalLevel:
+		enc.AppendString("FATAL")
+	default:
+		enc.AppendString(l.CapitalString())
+	}
+}
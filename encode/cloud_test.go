@@ -0,0 +1,55 @@
+package encode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGCPSeverity(t *testing.T) {
+	tests := []struct {
+		level zapcore.Level
+		want  string
+	}{
+		{level: zapcore.DebugLevel, want: "DEBUG"},
+		{level: zapcore.InfoLevel, want: "INFO"},
+		{level: zapcore.WarnLevel, want: "WARNING"},
+		{level: zapcore.ErrorLevel, want: "ERROR"},
+		{level: zapcore.DPanicLevel, want: "CRITICAL"},
+		{level: zapcore.PanicLevel, want: "ALERT"},
+		{level: zapcore.FatalLevel, want: "EMERGENCY"},
+		{level: zapcore.Level(-4), want: "DEFAULT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, GCPSeverity(tt.level))
+
+			enc := makeEncoder()
+			GCPSeverityEncoder(tt.level, enc)
+			enc.AssertCalled(t, "AppendString", tt.want)
+		})
+	}
+}
+
+func TestCloudWatchLevelEncoder(t *testing.T) {
+	tests := []struct {
+		level zapcore.Level
+		want  string
+	}{
+		{level: zapcore.DebugLevel, want: "DEBUG"},
+		{level: zapcore.InfoLevel, want: "INFO"},
+		{level: zapcore.WarnLevel, want: "WARN"},
+		{level: zapcore.ErrorLevel, want: "ERROR"},
+		{level: zapcore.DPanicLevel, want: "FATAL"},
+		{level: zapcore.PanicLevel, want: "FATAL"},
+		{level: zapcore.FatalLevel, want: "FATAL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			enc := makeEncoder()
+			CloudWatchLevelEncoder(tt.level, enc)
+			enc.AssertCalled(t, "AppendString", tt.want)
+		})
+	}
+}
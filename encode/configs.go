@@ -40,3 +40,18 @@ var JSONConfig = zapcore.EncoderConfig{
 	EncodeDuration: zapcore.SecondsDurationEncoder,
 	StacktraceKey:  "stacktrace",
 }
+
+// LogfmtConfig is a logfmt encoder config, with the keys Loki and Promtail
+// commonly expect
+var LogfmtConfig = zapcore.EncoderConfig{
+	TimeKey:        "time",
+	EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+	LevelKey:       "level",
+	EncodeLevel:    zapcore.LowercaseLevelEncoder,
+	NameKey:        "logger",
+	CallerKey:      "caller",
+	EncodeCaller:   zapcore.ShortCallerEncoder,
+	MessageKey:     "msg",
+	EncodeDuration: zapcore.StringDurationEncoder,
+	StacktraceKey:  "stacktrace",
+}
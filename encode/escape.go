@@ -0,0 +1,141 @@
+package encode
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+)
+
+const hex = "0123456789abcdef"
+
+// NeedsQuoting reports whether val has to be quoted to be written as a single
+// key=value pair: when it contains spaces, equal signs, quotes, backslashes,
+// control characters or invalid UTF-8
+func NeedsQuoting(val string) bool {
+	for _, r := range val {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendQuoted writes val in double quotes, escaping quotes and backslashes.
+// Newlines, tabs and carriage returns are written as \n, \t and \r, other
+// control and non printable characters as \u escapes, and invalid UTF-8 as
+// \ufffd, so val always ends up on a single line
+func AppendQuoted(buf *buffer.Buffer, val string) {
+	buf.AppendByte('"')
+	for i := 0; i < len(val); {
+		r, size := utf8.DecodeRuneInString(val[i:])
+		i += size
+
+		switch {
+		case r == '"' || r == '\\':
+			buf.AppendByte('\\')
+			buf.AppendByte(byte(r))
+		case r == '\n':
+			buf.AppendString(`\n`)
+		case r == '\r':
+			buf.AppendString(`\r`)
+		case r == '\t':
+			buf.AppendString(`\t`)
+		case r == utf8.RuneError && size == 1:
+			buf.AppendString(`\ufffd`)
+		case r < ' ' || r == 0x7f:
+			buf.AppendString(`\u00`)
+			buf.AppendByte(hex[r>>4])
+			buf.AppendByte(hex[r&0xf])
+		case !unicode.IsPrint(r) && r != ' ':
+			quoted := strconv.QuoteRuneToASCII(r)
+			buf.AppendString(quoted[1 : len(quoted)-1])
+		default:
+			buf.AppendString(string(r))
+		}
+	}
+	buf.AppendByte('"')
+}
+
+// AppendKey writes key, replacing spaces, equal signs, quotes and non
+// printable characters with an underscore. An empty key is written as _
+func AppendKey(buf *buffer.Buffer, key string) {
+	if len(key) == 0 {
+		buf.AppendByte('_')
+		return
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			buf.AppendByte('_')
+			continue
+		}
+		buf.AppendString(string(r))
+	}
+}
+
+// StripANSI removes ANSI escape sequences from s: CSI sequences such as
+// colors and cursor movements, OSC sequences such as titles and hyperlinks,
+// and other two byte escapes. A lone escape character is left in place
+func StripANSI(s string) string {
+	i := strings.IndexByte(s, '\x1b')
+	if i < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i >= 0 {
+		b.WriteString(s[:i])
+		n := ansiLength(s[i:])
+		if n == 0 {
+			// not a sequence, keep the escape so it can be escaped
+			b.WriteByte('\x1b')
+			n = 1
+		}
+		s = s[i+n:]
+		i = strings.IndexByte(s, '\x1b')
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// ansiLength returns the length of the escape sequence at the start of s, or
+// zero if s does not start with one
+func ansiLength(s string) int {
+	if len(s) < 2 {
+		return 0
+	}
+
+	switch s[1] {
+	case '[':
+		// CSI: parameter and intermediate bytes, then a final byte
+		for i := 2; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c >= 0x40 && c <= 0x7e:
+				return i + 1
+			case c < 0x20 || c > 0x7e:
+				return 0
+			}
+		}
+		return 0
+	case ']':
+		// OSC: terminated by BEL or ST
+		for i := 2; i < len(s); i++ {
+			switch {
+			case s[i] == '\a':
+				return i + 1
+			case s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\':
+				return i + 2
+			}
+		}
+		return 0
+	default:
+		if s[1] >= 0x40 && s[1] <= 0x7e {
+			return 2
+		}
+		return 0
+	}
+}
@@ -0,0 +1,100 @@
+package encode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/buffer"
+)
+
+func TestNeedsQuoting(t *testing.T) {
+	tests := []struct {
+		val  string
+		want bool
+	}{
+		{val: "plain", want: false},
+		{val: "héllo/wörld:42", want: false},
+		{val: "", want: false},
+		{val: "two words", want: true},
+		{val: "a=b", want: true},
+		{val: `"quoted"`, want: true},
+		{val: `back\slash`, want: true},
+		{val: "tab\t", want: true},
+		{val: "\x1b[31m", want: true},
+		{val: "invalid\xff", want: true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NeedsQuoting(tt.val), tt.val)
+	}
+}
+
+func TestAppendQuoted(t *testing.T) {
+	tests := []struct {
+		val  string
+		want string
+	}{
+		{val: "", want: `""`},
+		{val: "two words", want: `"two words"`},
+		{val: `say "hi"`, want: `"say \"hi\""`},
+		{val: `back\slash`, want: `"back\\slash"`},
+		{val: "line\nbreak\ttab\rreturn", want: `"line\nbreak\ttab\rreturn"`},
+		{val: "bell\x07", want: `"bell\u0007"`},
+		{val: "\x1b[31mred", want: `"\u001b[31mred"`},
+		{val: "del\x7f", want: `"del\u007f"`},
+		{val: "invalid\xff", want: `"invalid\ufffd"`},
+		{val: "zero\u200bwidth", want: `"zero\u200bwidth"`},
+		{val: "c1\u009bcsi", want: `"c1\u009bcsi"`},
+		{val: "héllo wörld", want: `"héllo wörld"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			buf := new(buffer.Buffer)
+			AppendQuoted(buf, tt.val)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestAppendKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "user.name", want: "user.name"},
+		{key: "@fields", want: "@fields"},
+		{key: "two words", want: "two_words"},
+		{key: `a="b"`, want: "a__b_"},
+		{key: "new\nline", want: "new_line"},
+		{key: "", want: "_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			buf := new(buffer.Buffer)
+			AppendKey(buf, tt.key)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "no sequences", s: "plain text", want: "plain text"},
+		{name: "colors", s: "\x1b[31mred\x1b[0m and \x1b[1;38;5;208morange\x1b[m", want: "red and orange"},
+		{name: "cursor movement", s: "\x1b[2J\x1b[Hcleared", want: "cleared"},
+		{name: "osc with bell", s: "\x1b]0;title\x07text", want: "text"},
+		{name: "osc with st", s: "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", want: "link"},
+		{name: "two byte escape", s: "\x1bcreset", want: "reset"},
+		{name: "lone escape", s: "end\x1b", want: "end\x1b"},
+		{name: "unterminated csi", s: "\x1b[31", want: "\x1b[31"},
+		{name: "control byte in csi", s: "\x1b[3\n1m", want: "\x1b[3\n1m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, StripANSI(tt.s))
+		})
+	}
+}
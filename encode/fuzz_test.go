@@ -0,0 +1,53 @@
+//go:build go1.18
+// +build go1.18
+
+package encode
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+)
+
+func FuzzStripANSI(f *testing.F) {
+	f.Add("\x1b[31mred\x1b[0m")
+	f.Add("\x1b]8;;https://example.com\x1b\\link")
+	f.Add("end\x1b")
+	f.Add("\x1b[\x1b[31mm")
+	f.Fuzz(func(t *testing.T, s string) {
+		if stripped := StripANSI(s); len(stripped) > len(s) {
+			t.Fatalf("StripANSI(%q) = %q is longer than its input", s, stripped)
+		}
+	})
+}
+
+func FuzzAppendQuoted(f *testing.F) {
+	f.Add("two words")
+	f.Add("\x1b[31mred\ninvalid\xff\u200b")
+	f.Fuzz(func(t *testing.T, s string) {
+		buf := new(buffer.Buffer)
+		AppendQuoted(buf, StripANSI(s))
+		quoted := buf.String()
+		if !utf8.ValidString(quoted) {
+			t.Fatalf("AppendQuoted(%q) = %q is not valid UTF-8", s, quoted)
+		}
+		for i := 0; i < len(quoted); i++ {
+			if c := quoted[i]; c < ' ' || c == 0x7f {
+				t.Fatalf("AppendQuoted(%q) = %q holds control character %#x", s, quoted, c)
+			}
+		}
+	})
+}
+
+func FuzzStyle_Sequence(f *testing.F) {
+	f.Add(uint8(1), uint8(208), uint8(0), uint8(0), true, false)
+	f.Fuzz(func(t *testing.T, kind, r, g, b uint8, bold, dim bool) {
+		shade := Shade{kind: shadeKind(kind % 4), r: r, g: g, b: b}
+		style := Style{Foreground: shade, Background: shade, Bold: bold, Dim: dim}
+		seq := style.Sequence()
+		if len(seq) > 0 && ansiLength(seq) != len(seq) {
+			t.Fatalf("Sequence() = %q is not a single escape sequence", seq)
+		}
+	})
+}
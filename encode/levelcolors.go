@@ -25,16 +25,14 @@ var (
 func init() {
 	for level, color := range levelColors {
 		levelcache[level] = color.Add(level.CapitalString())
+		DefaultTheme.Levels[level] = Style{Foreground: color.Shade()}
 	}
 }
 
 // ColorKey will color the provided key at the associated log level color
+// of the DefaultTheme
 func ColorKey(key string, level zapcore.Level) string {
-	c, ok := levelColors[level]
-	if !ok {
-		c = unknownLevelColor
-	}
-	return c.Add(key)
+	return DefaultTheme.ColorKey(key, level)
 }
 
 // CapitalColorLevel will apply coloring to the log level indicator
@@ -47,6 +45,10 @@ func CapitalColorLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 }
 
 // ColorBytesForLevel returns the byte sequence used to color keys for console output
+//
+// Deprecated: the two bytes only describe the basic colors of the default
+// levels, and the console encoder no longer rewrites escape sequences to
+// recolor keys. Use the Sequence of DefaultTheme.Level instead
 func ColorBytesForLevel(lvl zapcore.Level) (byte, byte) {
 	color, exists := levelColors[lvl]
 	if !exists {
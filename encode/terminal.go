@@ -0,0 +1,54 @@
+package encode
+
+import (
+	"io"
+	"os"
+)
+
+// ColorMode is when console output is colored
+type ColorMode int8
+
+// Supported color modes
+const (
+	// ColorAuto colors output written to a terminal. Setting the NO_COLOR
+	// environment variable turns colors off, and setting FORCE_COLOR turns
+	// them on when output is not written to a terminal
+	ColorAuto ColorMode = iota
+	// ColorAlways colors output regardless of where it is written
+	ColorAlways
+	// ColorNever never colors output
+	ColorNever
+)
+
+// Enabled reports whether output written to w is colored in the mode
+func (m ColorMode) Enabled(w io.Writer) bool {
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if len(os.Getenv("NO_COLOR")) > 0 {
+		return false
+	}
+	switch os.Getenv("FORCE_COLOR") {
+	case "", "0", "false":
+	default:
+		return true
+	}
+	return IsTerminal(w)
+}
+
+// IsTerminal reports whether w is a file connected to a terminal
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,63 @@
+package encode
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setenv sets or, with an empty value, unsets an environment variable until
+// the returned func is called
+func setenv(key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	if len(value) > 0 {
+		os.Setenv(key, value)
+	} else {
+		os.Unsetenv(key)
+	}
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestColorMode_Enabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       ColorMode
+		noColor    string
+		forceColor string
+		want       bool
+	}{
+		{name: "auto", mode: ColorAuto, want: false},
+		{name: "auto forced", mode: ColorAuto, forceColor: "1", want: true},
+		{name: "auto forced off", mode: ColorAuto, forceColor: "0", want: false},
+		{name: "auto no color", mode: ColorAuto, noColor: "1", forceColor: "1", want: false},
+		{name: "always", mode: ColorAlways, noColor: "1", want: true},
+		{name: "never", mode: ColorNever, forceColor: "1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setenv("NO_COLOR", tt.noColor)()
+			defer setenv("FORCE_COLOR", tt.forceColor)()
+			assert.Equal(t, tt.want, tt.mode.Enabled(new(bytes.Buffer)))
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	assert.False(t, IsTerminal(nil))
+	assert.False(t, IsTerminal(new(bytes.Buffer)))
+
+	f, err := ioutil.TempFile("", "terminal")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	assert.False(t, IsTerminal(f))
+}
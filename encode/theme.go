@@ -0,0 +1,139 @@
+package encode
+
+import (
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type shadeKind uint8
+
+const (
+	defaultShade shadeKind = iota
+	basicShade
+	paletteShade
+	rgbShade
+)
+
+// Shade is a foreground or background color of a Style: one of the basic
+// Colors, a color of the 256 color palette or a 24 bit color. The zero Shade
+// is the default color of the terminal
+type Shade struct {
+	kind    shadeKind
+	r, g, b uint8
+}
+
+// Shade returns the basic color c as a Shade
+func (c Color) Shade() Shade {
+	return Shade{kind: basicShade, r: uint8(c)}
+}
+
+// Color256 returns the color at index n of the 256 color palette
+func Color256(n uint8) Shade {
+	return Shade{kind: paletteShade, r: n}
+}
+
+// TrueColor returns a 24 bit color, which requires a terminal supporting
+// true color
+func TrueColor(r, g, b uint8) Shade {
+	return Shade{kind: rgbShade, r: r, g: g, b: b}
+}
+
+// appendParams appends the SGR parameters of the shade, as a foreground color
+// or as a background color
+func (s Shade) appendParams(params []string, background bool) []string {
+	prefix := "38"
+	if background {
+		prefix = "48"
+	}
+
+	switch s.kind {
+	case basicShade:
+		code := int(s.r)
+		if background {
+			code += 10
+		}
+		return append(params, strconv.Itoa(code))
+	case paletteShade:
+		return append(params, prefix, "5", strconv.Itoa(int(s.r)))
+	case rgbShade:
+		return append(params, prefix, "2", strconv.Itoa(int(s.r)), strconv.Itoa(int(s.g)), strconv.Itoa(int(s.b)))
+	default:
+		return params
+	}
+}
+
+// Style is how a piece of console output is rendered
+type Style struct {
+	Foreground Shade
+	Background Shade
+	Bold       bool
+	Dim        bool
+}
+
+// Sequence returns the escape sequence that starts the style, which is empty
+// for the zero Style
+func (s Style) Sequence() string {
+	var params []string
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if s.Dim {
+		params = append(params, "2")
+	}
+	params = s.Foreground.appendParams(params, false)
+	params = s.Background.appendParams(params, true)
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// Add renders s in the style
+func (s Style) Add(str string) string {
+	seq := s.Sequence()
+	if len(seq) == 0 {
+		return str
+	}
+	return seq + str + "\x1b[0m"
+}
+
+// Theme is how the console encoder colors its output
+type Theme struct {
+	// Levels are the styles of the level indicator and the keys of entries
+	// by level, and Unknown the style of levels missing from it
+	Levels  map[zapcore.Level]Style
+	Unknown Style
+	// Highlight and Faint are the styles of the frames of the main module,
+	// and of the runtime and standard library, in pretty stacktraces
+	Highlight Style
+	Faint     Style
+}
+
+// DefaultTheme colors each level with a basic color
+var DefaultTheme = Theme{
+	Levels:    make(map[zapcore.Level]Style, len(levelColors)),
+	Unknown:   Style{Foreground: unknownLevelColor.Shade()},
+	Highlight: Style{Bold: true},
+	Faint:     Style{Dim: true},
+}
+
+// Level returns the style of a level
+func (t *Theme) Level(l zapcore.Level) Style {
+	if s, ok := t.Levels[l]; ok {
+		return s
+	}
+	return t.Unknown
+}
+
+// ColorKey renders key in the style of level
+func (t *Theme) ColorKey(key string, level zapcore.Level) string {
+	return t.Level(level).Add(key)
+}
+
+// ColorLevel is a zapcore.LevelEncoder writing the level indicator in the
+// style of the level
+func (t *Theme) ColorLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(t.Level(l).Add(l.CapitalString()))
+}
@@ -0,0 +1,59 @@
+package encode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStyle_Sequence(t *testing.T) {
+	tests := []struct {
+		name  string
+		style Style
+		want  string
+	}{
+		{name: "zero", style: Style{}, want: ""},
+		{name: "bold", style: Style{Bold: true}, want: "\x1b[1m"},
+		{name: "dim", style: Style{Dim: true}, want: "\x1b[2m"},
+		{name: "basic", style: Style{Foreground: Cyan.Shade()}, want: "\x1b[36m"},
+		{name: "basic background", style: Style{Background: Red.Shade()}, want: "\x1b[41m"},
+		{name: "256 colors", style: Style{Foreground: Color256(208), Background: Color256(17)}, want: "\x1b[38;5;208;48;5;17m"},
+		{name: "true color", style: Style{Foreground: TrueColor(255, 128, 0), Background: TrueColor(0, 0, 51)}, want: "\x1b[38;2;255;128;0;48;2;0;0;51m"},
+		{name: "combined", style: Style{Foreground: Yellow.Shade(), Background: Blue.Shade(), Bold: true, Dim: true}, want: "\x1b[1;2;33;44m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.style.Sequence())
+		})
+	}
+}
+
+func TestStyle_Add(t *testing.T) {
+	assert.Equal(t, "plain", Style{}.Add("plain"))
+	assert.Equal(t, "\x1b[1;38;5;208mkey\x1b[0m", Style{Foreground: Color256(208), Bold: true}.Add("key"))
+}
+
+func TestTheme(t *testing.T) {
+	theme := Theme{
+		Levels: map[zapcore.Level]Style{
+			zapcore.InfoLevel: {Foreground: Color256(39)},
+		},
+		Unknown: Style{Foreground: TrueColor(255, 0, 0), Bold: true},
+	}
+
+	assert.Equal(t, "\x1b[38;5;39mkey\x1b[0m", theme.ColorKey("key", zapcore.InfoLevel))
+	assert.Equal(t, "\x1b[1;38;2;255;0;0mkey\x1b[0m", theme.ColorKey("key", zapcore.ErrorLevel))
+
+	enc := makeEncoder()
+	theme.ColorLevel(zapcore.InfoLevel, enc)
+	enc.AssertCalled(t, "AppendString", "\x1b[38;5;39mINFO\x1b[0m")
+}
+
+func TestDefaultTheme(t *testing.T) {
+	for level, color := range levelColors {
+		assert.Equal(t, color.Add("key"), DefaultTheme.ColorKey("key", level), level.String())
+		assert.Equal(t, levelcache[level], DefaultTheme.Level(level).Add(level.CapitalString()), level.String())
+	}
+	assert.Equal(t, "\x1b[31mkey\x1b[0m", DefaultTheme.ColorKey("key", zapcore.Level(19)))
+}
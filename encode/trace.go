@@ -0,0 +1,53 @@
+package encode
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys used for trace correlation
+const (
+	// TraceKey is the key of a trace field, and of the compact console field
+	TraceKey = "trace"
+	// TraceIDKey is the key of the trace id written by json encoders
+	TraceIDKey = "trace_id"
+	// SpanIDKey is the key of the span id written by json encoders
+	SpanIDKey = "span_id"
+)
+
+// Trace identifies the trace and span an entry was logged in. The json encoder
+// writes it as trace_id and span_id fields, and the console encoder as a single
+// trace=<trace id>/<span id> field. Other encoders write it as an object
+type Trace struct {
+	TraceID string
+	SpanID  string
+}
+
+// MarshalLogObject implements the zapcore ObjectMarshaler interface
+func (t Trace) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString(TraceIDKey, t.TraceID)
+	enc.AddString(SpanIDKey, t.SpanID)
+	return nil
+}
+
+// String returns the compact form of the trace
+func (t Trace) String() string {
+	return t.TraceID + "/" + t.SpanID
+}
+
+// TraceField returns a field holding the trace
+func TraceField(t Trace) zapcore.Field {
+	return zapcore.Field{
+		Key:       TraceKey,
+		Type:      zapcore.ObjectMarshalerType,
+		Interface: t,
+	}
+}
+
+// TraceOf returns the trace held by a field created with TraceField
+func TraceOf(f zapcore.Field) (Trace, bool) {
+	if f.Type != zapcore.ObjectMarshalerType {
+		return Trace{}, false
+	}
+	t, ok := f.Interface.(Trace)
+	return t, ok
+}
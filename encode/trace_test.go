@@ -0,0 +1,33 @@
+package encode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTraceOf(t *testing.T) {
+	trace := Trace{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+
+	got, ok := TraceOf(TraceField(trace))
+	assert.True(t, ok)
+	assert.Equal(t, trace, got)
+
+	_, ok = TraceOf(zap.String("trace", trace.String()))
+	assert.False(t, ok)
+	_, ok = TraceOf(zap.Object("trace", zapcore.ObjectMarshalerFunc(trace.MarshalLogObject)))
+	assert.False(t, ok)
+}
+
+func TestTrace_MarshalLogObject(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	trace := Trace{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+	assert.NoError(t, trace.MarshalLogObject(enc))
+	assert.Equal(t, map[string]interface{}{
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+	}, enc.Fields)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736/00f067aa0ba902b7", trace.String())
+}
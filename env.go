@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap/zapcore"
+)
+
+// Environment variables read by FromEnv
+const (
+	// EnvMode is the mode loggers run in: development, production or none
+	EnvMode = "LOG_MODE"
+	// EnvLevel is the default log level, for example debug or warn
+	EnvLevel = "LOG_LEVEL"
+	// EnvAppName is the value of the "application" field
+	EnvAppName = "LOG_APP_NAME"
+	// EnvJSONSink is where json formatted output is written: stdout,
+	// stderr or the path of a file that output is appended to
+	EnvJSONSink = "LOG_JSON_SINK"
+	// EnvLevels is a comma separated list of package=level pairs,
+	// for example github.com/acme/db=debug,main=warn
+	EnvLevels = "LOG_LEVELS"
+)
+
+// FromEnv returns the options described by the LOG_* environment variables,
+// ready to be passed to Configure. Variables that are not set are ignored, and
+// an error is returned if any of them holds an invalid value
+func FromEnv() ([]Option, error) {
+	return fromEnv(os.LookupEnv)
+}
+
+func fromEnv(lookup func(string) (string, bool)) ([]Option, error) {
+	var options []Option
+
+	if val, ok := lookup(EnvMode); ok {
+		m, err := mode.Parse(val)
+		if err != nil {
+			return nil, envError(EnvMode, val, err)
+		}
+		options = append(options, Mode(m))
+	}
+
+	if val, ok := lookup(EnvLevel); ok {
+		lvl, err := parseLevel(val)
+		if err != nil {
+			return nil, envError(EnvLevel, val, err)
+		}
+		options = append(options, Level(lvl))
+	}
+
+	if val, ok := lookup(EnvAppName); ok {
+		options = append(options, AppName(val))
+	}
+
+	if val, ok := lookup(EnvLevels); ok {
+		levels, err := parsePackageLevels(val)
+		if err != nil {
+			return nil, envError(EnvLevels, val, err)
+		}
+		for pkg, lvl := range levels {
+			options = append(options, LevelForPackage(pkg, lvl))
+		}
+	}
+
+	// the sink is opened last, so that no file is left open when
+	// another variable is invalid
+	if val, ok := lookup(EnvJSONSink); ok {
+		w, err := openSink(val)
+		if err != nil {
+			return nil, envError(EnvJSONSink, val, err)
+		}
+		options = append(options, JSONWriter(w))
+	}
+
+	return options, nil
+}
+
+func envError(key, val string, err error) error {
+	return fmt.Errorf("logger: invalid value %q for %s: %v", val, key, err)
+}
+
+func parseLevel(name string) (zapcore.Level, error) {
+	var lvl zapcore.Level
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return lvl, errors.New("empty level")
+	}
+	err := lvl.UnmarshalText([]byte(name))
+	return lvl, err
+}
+
+// parsePackageLevels parses a comma separated list of package=level pairs
+func parsePackageLevels(spec string) (map[string]zapcore.Level, error) {
+	levels := make(map[string]zapcore.Level)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected package=level, got %q", pair)
+		}
+
+		pkg := strings.TrimSpace(parts[0])
+		if len(pkg) == 0 {
+			return nil, fmt.Errorf("missing package in %q", pair)
+		}
+
+		lvl, err := parseLevel(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %v", pkg, err)
+		}
+		levels[pkg] = lvl
+	}
+	return levels, nil
+}
+
+// openSink returns the writer for a sink name: stdout, stderr or a file path
+func openSink(name string) (io.Writer, error) {
+	switch strings.TrimSpace(name) {
+	case "":
+		return nil, errors.New("empty sink")
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "nothing set",
+			env:  map[string]string{},
+			want: Config{},
+		},
+		{
+			name: "all set",
+			env: map[string]string{
+				EnvMode:     "production",
+				EnvLevel:    "warn",
+				EnvAppName:  "my-app",
+				EnvJSONSink: "stderr",
+				EnvLevels:   "github.com/acme/db=debug, main=error,",
+			},
+			want: Config{
+				mode:     mode.Production,
+				level:    zap.WarnLevel,
+				levelSet: true,
+				appname:  "my-app",
+				jsink:    os.Stderr,
+				pkglevels: map[string]zapcore.Level{
+					"github.com/acme/db": zap.DebugLevel,
+					"main":               zap.ErrorLevel,
+				},
+			},
+		},
+		{
+			name:    "invalid mode",
+			env:     map[string]string{EnvMode: "staging"},
+			wantErr: `logger: invalid value "staging" for LOG_MODE: unrecognized mode: "staging"`,
+		},
+		{
+			name:    "invalid level",
+			env:     map[string]string{EnvLevel: "loud"},
+			wantErr: `logger: invalid value "loud" for LOG_LEVEL: unrecognized level: "loud"`,
+		},
+		{
+			name:    "empty level",
+			env:     map[string]string{EnvLevel: ""},
+			wantErr: `logger: invalid value "" for LOG_LEVEL: empty level`,
+		},
+		{
+			name:    "package level without separator",
+			env:     map[string]string{EnvLevels: "main=warn,github.com/acme/db"},
+			wantErr: `logger: invalid value "main=warn,github.com/acme/db" for LOG_LEVELS: expected package=level, got "github.com/acme/db"`,
+		},
+		{
+			name:    "package level with invalid level",
+			env:     map[string]string{EnvLevels: "main=chatty"},
+			wantErr: `logger: invalid value "main=chatty" for LOG_LEVELS: package main: unrecognized level: "chatty"`,
+		},
+		{
+			name:    "package level without package",
+			env:     map[string]string{EnvLevels: "=warn"},
+			wantErr: `logger: invalid value "=warn" for LOG_LEVELS: missing package in "=warn"`,
+		},
+		{
+			name:    "unopenable json sink",
+			env:     map[string]string{EnvJSONSink: filepath.Join("does", "not", "exist.log")},
+			wantErr: "logger: invalid value \"does/not/exist.log\" for LOG_JSON_SINK: open does/not/exist.log: no such file or directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := fromEnv(lookupFrom(tt.env))
+			if len(tt.wantErr) > 0 {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			cfg := new(Config)
+			for _, opt := range options {
+				opt(cfg)
+			}
+			assert.Equal(t, tt.want, *cfg)
+		})
+	}
+}
+
+func TestFromEnv_invalidWithSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger-env")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	_, err = fromEnv(lookupFrom(map[string]string{
+		EnvJSONSink: path,
+		EnvLevels:   "main=chatty",
+	}))
+	assert.Error(t, err)
+
+	// the sink file is not opened when another variable is invalid
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFromEnv_Configure(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	options, err := fromEnv(lookupFrom(map[string]string{
+		EnvLevels: "github.com/acme/db=debug",
+	}))
+	assert.NoError(t, err)
+
+	Configure(options...)
+	assert.Equal(t, zap.DebugLevel, registry.Get("github.com/acme/db/postgres").Level())
+	assert.Nil(t, global.pkglevels, "package levels should only be applied once")
+}
@@ -0,0 +1,174 @@
+package file
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// backupTimeFormat is the layout of the rotation time in backup names
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressedSuffix = ".gz"
+)
+
+// backup is a rotated file
+type backup struct {
+	path      string
+	rotatedAt time.Time
+	// seq tells apart backups rotated within the same millisecond
+	seq int
+}
+
+// split returns the directory, the name without extension and the extension of path
+func split(path string) (dir, prefix, ext string) {
+	dir, name := filepath.Split(path)
+	ext = filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext), ext
+}
+
+// backupName returns an unused name for a backup rotated at t
+func (s *Sink) backupName(t time.Time) string {
+	dir, prefix, ext := split(s.path)
+	stamp := prefix + "-" + t.UTC().Format(backupTimeFormat)
+
+	name := filepath.Join(dir, stamp+ext)
+	for i := 1; exists(name) || exists(name+compressedSuffix); i++ {
+		name = filepath.Join(dir, fmt.Sprintf("%s-%d%s", stamp, i, ext))
+	}
+	return name
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// backups returns the rotated files of the sink, newest first
+func (s *Sink) backups() ([]backup, error) {
+	dir, prefix, ext := split(s.path)
+	if len(dir) == 0 {
+		dir = "."
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+
+		name := strings.TrimSuffix(info.Name(), compressedSuffix)
+		if !strings.HasPrefix(name, prefix+"-") || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix+"-"), ext)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)])
+		if err != nil {
+			continue
+		}
+
+		var seq int
+		if rest := stamp[len(backupTimeFormat):]; len(rest) > 0 {
+			if seq, err = strconv.Atoi(strings.TrimPrefix(rest, "-")); err != nil || rest[0] != '-' {
+				continue
+			}
+		}
+
+		backups = append(backups, backup{
+			path:      filepath.Join(dir, info.Name()),
+			rotatedAt: t,
+			seq:       seq,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].rotatedAt.Equal(backups[j].rotatedAt) {
+			return backups[i].seq > backups[j].seq
+		}
+		return backups[i].rotatedAt.After(backups[j].rotatedAt)
+	})
+	return backups, nil
+}
+
+// mill removes backups beyond the maximum count or age, and compresses the rest
+func (s *Sink) mill() {
+	defer s.pending.Done()
+
+	s.milling.Lock()
+	defer s.milling.Unlock()
+
+	backups, err := s.backups()
+	if err != nil {
+		return
+	}
+
+	var keep []backup
+	cutoff := s.now().Add(-s.maxAge)
+	for i, b := range backups {
+		if s.maxBackups > 0 && i >= s.maxBackups {
+			os.Remove(b.path)
+			continue
+		}
+		if s.maxAge > 0 && b.rotatedAt.Before(cutoff) {
+			os.Remove(b.path)
+			continue
+		}
+		keep = append(keep, b)
+	}
+
+	if !s.compress {
+		return
+	}
+	for _, b := range keep {
+		if !strings.HasSuffix(b.path, compressedSuffix) {
+			compress(b.path)
+		}
+	}
+}
+
+// compress gzips the file at path and removes it, leaving
+// it in place if it could not be compressed
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressedSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + compressedSuffix)
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,142 @@
+package file
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSink_backupName(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	s := &Sink{path: filepath.Join(dir, "app.log")}
+	at := time.Date(2021, 3, 14, 10, 0, 0, 0, time.FixedZone("", 3600))
+
+	first := s.backupName(at)
+	assert.Equal(t, filepath.Join(dir, "app-2021-03-14T09-00-00.000.log"), first)
+
+	writeFile(t, first)
+	writeFile(t, filepath.Join(dir, "app-2021-03-14T09-00-00.000-1.log.gz"))
+	assert.Equal(t, filepath.Join(dir, "app-2021-03-14T09-00-00.000-2.log"), s.backupName(at))
+}
+
+func writeFile(t *testing.T, path string) {
+	if err := ioutil.WriteFile(path, []byte(path), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSink_backups(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{
+		"app.log",
+		"app-2021-03-14T09-00-00.000.log",
+		"app-2021-03-14T11-00-00.000.log.gz",
+		"app-2021-03-14T10-00-00.000.log",
+		"app-2021-03-14T10-00-00.000-1.log",
+		"app-other.log",
+		"app-2021-03-14T12-00-00.000.txt",
+		"app-2021-03-14T12-00-00.000x.log",
+		"api-2021-03-14T12-00-00.000.log",
+	} {
+		writeFile(t, filepath.Join(dir, name))
+	}
+
+	s := &Sink{path: filepath.Join(dir, "app.log")}
+	backups, err := s.backups()
+	assert.NoError(t, err)
+
+	var names []string
+	for _, b := range backups {
+		names = append(names, filepath.Base(b.path))
+	}
+	assert.Equal(t, []string{
+		"app-2021-03-14T11-00-00.000.log.gz",
+		"app-2021-03-14T10-00-00.000-1.log",
+		"app-2021-03-14T10-00-00.000.log",
+		"app-2021-03-14T09-00-00.000.log",
+	}, names)
+}
+
+func TestSink_mill(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		want    []string
+	}{
+		{
+			name:    "max backups",
+			options: []Option{MaxBackups(2)},
+			want: []string{
+				"app-2021-03-14T13-00-00.000.log",
+				"app-2021-03-14T14-00-00.000.log",
+				"app.log",
+			},
+		},
+		{
+			name:    "max age",
+			options: []Option{MaxAge(90 * time.Minute)},
+			want: []string{
+				"app-2021-03-14T13-00-00.000.log",
+				"app-2021-03-14T14-00-00.000.log",
+				"app.log",
+			},
+		},
+		{
+			name:    "compress",
+			options: []Option{MaxBackups(3), Compress()},
+			want: []string{
+				"app-2021-03-14T12-00-00.000.log.gz",
+				"app-2021-03-14T13-00-00.000.log.gz",
+				"app-2021-03-14T14-00-00.000.log.gz",
+				"app.log",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+
+			c := &clock{t: time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC)}
+			s := newTestSink(t, dir, c, tt.options...)
+			for i := 0; i < 4; i++ {
+				c.advance(time.Hour)
+				s.Write([]byte("entry\n"))
+				assert.NoError(t, s.Rotate())
+			}
+			assert.NoError(t, s.Close())
+			assert.Equal(t, tt.want, files(t, dir))
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app-2021-03-14T10-00-00.000.log")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("entry\n"), 0644))
+	assert.NoError(t, compress(path))
+
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	f, err := os.Open(path + compressedSuffix)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	contents, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, "entry\n", string(contents))
+}
@@ -0,0 +1,250 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrClosed is returned when writing to a Sink that has been closed
+var ErrClosed = errors.New("the file sink has been closed")
+
+// retryRotateAfter is how long a Sink keeps writing to the current file
+// after failing to rotate it, before trying again
+const retryRotateAfter = time.Minute
+
+// An Option can be used to configure a Sink
+type Option func(s *Sink)
+
+// MaxSize rotates the file before a write would grow it beyond size bytes. A
+// single entry larger than size is still written, to a file of its own
+func MaxSize(size int64) Option {
+	return func(s *Sink) {
+		s.maxSize = size
+	}
+}
+
+// Every rotates the file at every multiple of interval, such as each hour or
+// each day. Intervals are aligned to UTC, so daily rotation happens at midnight UTC
+func Every(interval time.Duration) Option {
+	return func(s *Sink) {
+		s.interval = interval
+	}
+}
+
+// MaxBackups removes the oldest rotated files when there are more than n
+func MaxBackups(n int) Option {
+	return func(s *Sink) {
+		s.maxBackups = n
+	}
+}
+
+// MaxAge removes rotated files once they are older than d
+func MaxAge(d time.Duration) Option {
+	return func(s *Sink) {
+		s.maxAge = d
+	}
+}
+
+// Compress gzips rotated files
+func Compress() Option {
+	return func(s *Sink) {
+		s.compress = true
+	}
+}
+
+// Sink is a zapcore.WriteSyncer that writes to a file and rotates it on size
+// and time. Rotated files are kept next to it as backups, named after the
+// file and the time of rotation, for example app-2021-03-14T10-00-00.000.log.
+// Compression and removal of backups happen in the background
+type Sink struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+	now        func() time.Time
+	rename     func(from, to string) error
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	rotateAt time.Time
+	retryAt  time.Time
+	closed   bool
+
+	// milling serializes compression and removal of backups
+	milling sync.Mutex
+	pending sync.WaitGroup
+}
+
+var _ zapcore.WriteSyncer = (*Sink)(nil)
+
+// NewSink opens, or creates, the file at path for appending and returns a Sink
+// writing to it. Missing directories are created
+func NewSink(path string, options ...Option) (*Sink, error) {
+	s := &Sink{
+		path:   path,
+		now:    time.Now,
+		rename: os.Rename,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Sink) Write(p []byte) (n int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return 0, ErrClosed
+	}
+	if s.file == nil {
+		// the file could not be opened again after rotating it
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	var rotateErr error
+	if s.due(int64(len(p))) {
+		rotateErr = s.rotate()
+		if s.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	// entries are still written to the current file when rotating it failed
+	n, err = s.file.Write(p)
+	s.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Sync commits the contents of the current file to stable storage
+func (s *Sink) Sync() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed || s.file == nil {
+		return nil
+	}
+	return s.file.Sync()
+}
+
+// Rotate closes the current file, keeps it as a backup and opens a new one,
+// regardless of its size or age
+func (s *Sink) Rotate() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	return s.rotate()
+}
+
+// Close closes the file and waits for pending compression and removal of backups
+func (s *Sink) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	var err error
+	if s.file != nil {
+		err = s.file.Close()
+	}
+	s.mutex.Unlock()
+
+	s.pending.Wait()
+	return err
+}
+
+// open opens the file at path for appending. The caller must hold the mutex
+func (s *Sink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	s.file = f
+	s.size = info.Size()
+	if s.interval > 0 {
+		s.rotateAt = s.now().Truncate(s.interval).Add(s.interval)
+	}
+	return nil
+}
+
+// due reports whether the file must be rotated before writing n bytes.
+// The caller must hold the mutex
+func (s *Sink) due(n int64) bool {
+	if s.now().Before(s.retryAt) {
+		return false
+	}
+	if s.maxSize > 0 && s.size > 0 && s.size+n > s.maxSize {
+		return true
+	}
+	return s.interval > 0 && !s.now().Before(s.rotateAt)
+}
+
+// rotate renames the current file to a backup and opens a new one. When that
+// fails the current file is reopened, so that writing can go on, and rotation
+// is not attempted again for a while. The caller must hold the mutex
+func (s *Sink) rotate() error {
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return s.reopen(err)
+		}
+	}
+
+	backup := s.backupName(s.now())
+	if err := s.rename(s.path, backup); err != nil && !os.IsNotExist(err) {
+		return s.reopen(err)
+	}
+
+	if err := s.open(); err != nil {
+		s.file = nil
+		return err
+	}
+
+	s.pending.Add(1)
+	go s.mill()
+	return nil
+}
+
+// reopen opens the current file again after rotating it failed with err.
+// The caller must hold the mutex
+func (s *Sink) reopen(err error) error {
+	s.retryAt = s.now().Add(retryRotateAfter)
+	if openErr := s.open(); openErr != nil {
+		s.file = nil
+		return openErr
+	}
+	return fmt.Errorf("file: rotate %s: %v", s.path, err)
+}
@@ -0,0 +1,220 @@
+package file
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a manually advanced time source
+type clock struct {
+	mutex sync.Mutex
+	t     time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "file-sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// newTestSink returns a sink writing app.log in dir, driven by c
+func newTestSink(t *testing.T, dir string, c *clock, options ...Option) *Sink {
+	options = append(options, func(s *Sink) { s.now = c.now })
+	s, err := NewSink(filepath.Join(dir, "app.log"), options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// files returns the names of the files in dir, sorted
+func files(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func read(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSink_MaxSize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c := &clock{t: time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC)}
+	s := newTestSink(t, dir, c, MaxSize(11))
+
+	for _, entry := range []string{"12345\n", "6789\n", "abcde\n", "a much longer entry\n"} {
+		n, err := s.Write([]byte(entry))
+		assert.NoError(t, err)
+		assert.Equal(t, len(entry), n)
+		c.advance(time.Second)
+	}
+	assert.NoError(t, s.Close())
+
+	assert.Equal(t, []string{
+		"app-2021-03-14T10-00-02.000.log",
+		"app-2021-03-14T10-00-03.000.log",
+		"app.log",
+	}, files(t, dir))
+	assert.Equal(t, "12345\n6789\n", read(t, filepath.Join(dir, "app-2021-03-14T10-00-02.000.log")))
+	assert.Equal(t, "abcde\n", read(t, filepath.Join(dir, "app-2021-03-14T10-00-03.000.log")))
+	assert.Equal(t, "a much longer entry\n", read(t, filepath.Join(dir, "app.log")))
+}
+
+func TestSink_renameFails(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	renames := 0
+	failing := errors.New("device busy")
+	rename := func(s *Sink) {
+		s.rename = func(from, to string) error {
+			renames++
+			if renames == 1 {
+				return failing
+			}
+			return os.Rename(from, to)
+		}
+	}
+
+	c := &clock{t: time.Date(2021, 3, 14, 10, 0, 0, 0, time.UTC)}
+	s := newTestSink(t, dir, c, MaxSize(6), rename)
+
+	_, err := s.Write([]byte("first\n"))
+	assert.NoError(t, err)
+
+	// the entry is written to the current file along with the rotation error
+	n, err := s.Write([]byte("second\n"))
+	assert.Equal(t, 7, n)
+	assert.EqualError(t, err, "file: rotate "+filepath.Join(dir, "app.log")+": device busy")
+
+	// rotation is not retried on every write
+	n, err = s.Write([]byte("third\n"))
+	assert.Equal(t, 6, n)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renames)
+	assert.Equal(t, "first\nsecond\nthird\n", read(t, filepath.Join(dir, "app.log")))
+
+	c.advance(retryRotateAfter)
+	_, err = s.Write([]byte("fourth\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Close())
+
+	assert.Equal(t, 2, renames)
+	assert.Equal(t, []string{"app-2021-03-14T10-01-00.000.log", "app.log"}, files(t, dir))
+	assert.Equal(t, "fourth\n", read(t, filepath.Join(dir, "app.log")))
+}
+
+func TestSink_Every(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	c := &clock{t: time.Date(2021, 3, 14, 10, 59, 0, 0, time.UTC)}
+	s := newTestSink(t, dir, c, Every(time.Hour))
+
+	s.Write([]byte("first hour\n"))
+	c.advance(30 * time.Second)
+	s.Write([]byte("still first hour\n"))
+	c.advance(time.Minute)
+	s.Write([]byte("second hour\n"))
+	assert.NoError(t, s.Close())
+
+	assert.Equal(t, []string{"app-2021-03-14T11-00-30.000.log", "app.log"}, files(t, dir))
+	assert.Equal(t, "first hour\nstill first hour\n", read(t, filepath.Join(dir, "app-2021-03-14T11-00-30.000.log")))
+	assert.Equal(t, "second hour\n", read(t, filepath.Join(dir, "app.log")))
+}
+
+func TestSink_appends(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "app.log")
+	for _, entry := range []string{"one\n", "two\n"} {
+		s, err := NewSink(path, MaxSize(100))
+		assert.NoError(t, err)
+		s.Write([]byte(entry))
+		assert.NoError(t, s.Sync())
+		assert.NoError(t, s.Close())
+	}
+	assert.Equal(t, "one\ntwo\n", read(t, path))
+}
+
+func TestSink_closed(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	s := newTestSink(t, dir, &clock{t: time.Now()})
+	assert.NoError(t, s.Close())
+	assert.NoError(t, s.Close())
+
+	_, err := s.Write([]byte("late"))
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, ErrClosed, s.Rotate())
+	assert.NoError(t, s.Sync())
+}
+
+func TestSink_concurrentWrites(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	s := newTestSink(t, dir, &clock{t: time.Now()}, MaxSize(1000))
+
+	const writers, writes = 8, 100
+	entry := []byte("0123456789\n")
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				s.Write(entry)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, s.Close())
+
+	var total int
+	for _, name := range files(t, dir) {
+		contents := read(t, filepath.Join(dir, name))
+		assert.True(t, len(contents) <= 1000, name)
+		total += len(contents)
+	}
+	assert.Equal(t, writers*writes*len(entry), total)
+}
@@ -2,57 +2,164 @@ package registry
 
 import (
 	"errors"
-	"sync"
+	"strings"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// ErrPkgNotRegistered is returned when a package is not in the registry
-var ErrPkgNotRegistered = errors.New("the provided package is not in the registry")
+var (
+	// ErrPkgNotRegistered is returned when a package is not in the registry
+	ErrPkgNotRegistered = errors.New("the provided package is not in the registry")
+
+	// ErrNoOverride is returned when clearing a package that has no explicit level
+	ErrNoOverride = errors.New("the provided package does not have an explicit level")
+)
 
 // Package is string representation of a Go package
 type Package string
 
-var (
-	levels       = make(map[Package]zap.AtomicLevel)
-	defaultLevel = zapcore.InfoLevel
-	mutex        sync.Mutex
+// parent returns the package one level up the import path tree
+func (p Package) parent() (Package, bool) {
+	i := strings.LastIndexByte(string(p), '/')
+	if i < 0 {
+		return "", false
+	}
+	return p[:i], true
+}
+
+// Lineage returns the package followed by each of its ancestors, nearest first,
+// which is the order in which settings made on import path prefixes apply
+func (p Package) Lineage() []Package {
+	lineage := []Package{p}
+	for parent, ok := p.parent(); ok; parent, ok = parent.parent() {
+		lineage = append(lineage, parent)
+	}
+	return lineage
+}
+
+// contains reports whether other is the package itself or one of its descendants
+func (p Package) contains(other Package) bool {
+	return p == other || strings.HasPrefix(string(other), string(p)+"/")
+}
+
+// Source identifies what made a change to the registry
+type Source string
+
+// Sources of changes made by this module
+const (
+	SourceAPI       Source = "api"
+	SourceAdmin     Source = "admin"
+	SourceConfigure Source = "configure"
+	SourceFile      Source = "file"
 )
 
-// SetDefaultLevel sets the default log level applied to the registry
-func SetDefaultLevel(level zapcore.Level) {
-	defaultLevel = level
+// Entry describes the level state of a registered package
+type Entry struct {
+	Package Package
+	// Effective is the level currently applied to loggers in the package
+	Effective zapcore.Level
+	// Explicit is true when the level was set on the package itself
+	Explicit bool
+	// From is the package, or ancestor prefix, the effective level
+	// was resolved from. It is empty when the default level applies
+	From Package
 }
 
-// Set a log level for logger instances in the provided package
-// Set returns a non nil error
-func Set(pkg Package, level zapcore.Level) error {
-	lvl, ok := levels[pkg]
-	if !ok {
-		return ErrPkgNotRegistered
-	}
-	lvl.SetLevel(level)
-	return nil
+// Event describes a change to the effective level of a registered package
+type Event struct {
+	Package Package
+	Old     zapcore.Level
+	New     zapcore.Level
+	// Target is the package, prefix or glob the change was made on.
+	// It is empty when the default level was changed
+	Target Package
+	Source Source
+}
+
+// std is the registry shared by all logger instances
+var std = newStore()
+
+// SetDefaultLevel sets the default log level applied to the registry. Packages
+// without an explicit level, either on themselves or on an ancestor, are updated
+func SetDefaultLevel(level zapcore.Level, source Source) {
+	std.setDefault(level, source)
+}
+
+// DefaultLevel returns the log level applied to packages without an explicit level
+func DefaultLevel() zapcore.Level {
+	return std.getDefault()
+}
+
+// Set a log level for logger instances in the provided package and all of its
+// descendants that do not have a more specific level of their own. The package
+// may be a prefix of the import path of registered packages, in which case the
+// level is inherited by packages that register later as well.
+// Set returns a non nil error if the package is neither registered nor a prefix
+// of a registered package
+func Set(pkg Package, level zapcore.Level, source Source) error {
+	return std.set(pkg, level, source)
+}
+
+// Declare sets an explicit log level on the provided package or prefix like Set,
+// but without requiring it to be registered, so that levels can be configured
+// before the loggers of a package are created
+func Declare(pkg Package, level zapcore.Level, source Source) {
+	std.declare(pkg, level, source)
+}
+
+// SetMatching sets an explicit log level for every registered package whose
+// name matches the provided glob pattern, using the syntax of path.Match. It
+// returns the packages that were updated, or ErrPkgNotRegistered when the
+// pattern matched nothing
+func SetMatching(pattern string, level zapcore.Level, source Source) ([]Package, error) {
+	return std.setMatching(pattern, level, source)
+}
+
+// Clear removes the explicit level of the provided package, so that it,
+// and its descendants, fall back to the level of the nearest ancestor
+func Clear(pkg Package, source Source) error {
+	return std.clear(pkg, source)
 }
 
 // Get returns the atomic log level for the provided package name
 func Get(pkg Package) zap.AtomicLevel {
-	mutex.Lock()
-	defer mutex.Unlock()
-	lvl, ok := levels[pkg]
-	if !ok {
-		lvl = zap.NewAtomicLevelAt(defaultLevel)
-		levels[pkg] = lvl
-	}
-	return lvl
+	return std.get(pkg)
 }
 
 // GetPackages returns all packages in the registry
 func GetPackages() []Package {
-	pkgs := make([]Package, 0, len(levels))
-	for pkg := range levels {
-		pkgs = append(pkgs, pkg)
-	}
-	return pkgs
+	return std.packages()
+}
+
+// Entries returns the level state of every registered package, sorted by name
+func Entries() []Entry {
+	return std.entries()
+}
+
+// Overrides returns all explicitly set levels, keyed by package or prefix
+func Overrides() map[Package]zapcore.Level {
+	return std.explicit()
+}
+
+// Watch registers fn to be called with an Event for every change to the
+// effective level of a registered package. Events are delivered synchronously,
+// before the change returns, in the order changes were applied: by the goroutine
+// that made the change or by one delivering the events of a concurrent change.
+// Changes that leave every effective level as it was produce no events. fn may
+// query the registry, but must not change it. The returned func removes the watcher
+func Watch(fn func(Event)) (cancel func()) {
+	return std.watch(fn)
+}
+
+// Reset removes all packages, explicit levels and watchers from the
+// registry and restores the default level to info
+func Reset() {
+	std.mutex.Lock()
+	defer std.mutex.Unlock()
+	std.levels = make(map[Package]zap.AtomicLevel)
+	std.overrides = make(map[Package]zapcore.Level)
+	std.defaultLevel = zapcore.InfoLevel
+	std.watchers = make(map[int]func(Event))
+	std.queued = nil
 }
@@ -1,7 +1,10 @@
 package registry
 
 import (
+	"fmt"
 	"reflect"
+	"runtime"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,8 +47,8 @@ func TestSetDefaultLevel(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			SetDefaultLevel(tt.args.level)
-			assert.Equal(t, defaultLevel, tt.args.level)
+			SetDefaultLevel(tt.args.level, SourceAPI)
+			assert.Equal(t, tt.args.level, DefaultLevel())
 		})
 	}
 }
@@ -67,7 +70,7 @@ func TestGet(t *testing.T) {
 				pkg: "my/package/name",
 			},
 			setup: func() {
-				defaultLevel = zap.InfoLevel
+				std.defaultLevel = zap.InfoLevel
 			},
 			want: zap.NewAtomicLevelAt(zap.InfoLevel),
 		},
@@ -77,7 +80,7 @@ func TestGet(t *testing.T) {
 				pkg: "my/package/name",
 			},
 			setup: func() {
-				defaultLevel = zap.WarnLevel
+				std.defaultLevel = zap.WarnLevel
 			},
 			want: zap.NewAtomicLevelAt(zap.InfoLevel),
 		},
@@ -87,7 +90,7 @@ func TestGet(t *testing.T) {
 				pkg: "my/package/name",
 			},
 			setup: func() {
-				Set("my/package/name", zap.DebugLevel)
+				Set("my/package/name", zap.DebugLevel, SourceAPI)
 			},
 			want: zap.NewAtomicLevelAt(zap.DebugLevel),
 		},
@@ -138,7 +141,7 @@ func TestSet(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := Set(tt.args.pkg, tt.args.level); (err != nil) != tt.wantErr {
+			if err := Set(tt.args.pkg, tt.args.level, SourceAPI); (err != nil) != tt.wantErr {
 				tt.setup()
 				t.Errorf("Set() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -160,17 +163,17 @@ func TestGetPackages(t *testing.T) {
 		{
 			name: "empty",
 			setup: func() {
-				levels = make(map[Package]zap.AtomicLevel)
+				std.levels = make(map[Package]zap.AtomicLevel)
 			},
 			want: []Package{},
 		},
 		{
 			name: "all registered",
 			setup: func() {
-				levels = make(map[Package]zap.AtomicLevel)
-				levels[Package("alpha")] = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-				levels[Package("beta")] = zap.NewAtomicLevelAt(zapcore.WarnLevel)
-				levels[Package("kappa")] = zap.NewAtomicLevelAt(zapcore.ErrorLevel)
+				std.levels = make(map[Package]zap.AtomicLevel)
+				std.levels[Package("alpha")] = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+				std.levels[Package("beta")] = zap.NewAtomicLevelAt(zapcore.WarnLevel)
+				std.levels[Package("kappa")] = zap.NewAtomicLevelAt(zapcore.ErrorLevel)
 			},
 			want: []Package{"alpha", "beta", "kappa"},
 		},
@@ -184,3 +187,245 @@ func TestGetPackages(t *testing.T) {
 		})
 	}
 }
+
+func TestSetMatching(t *testing.T) {
+	type args struct {
+		pattern string
+		level   zapcore.Level
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setup   func()
+		want    []Package
+		wantErr error
+	}{
+		{
+			name: "matches children",
+			args: args{
+				pattern: "acme/svc/*",
+				level:   zap.DebugLevel,
+			},
+			setup: func() {
+				std.levels = make(map[Package]zap.AtomicLevel)
+				std.levels[Package("acme/svc")] = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+				std.levels[Package("acme/svc/store")] = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+				std.levels[Package("acme/svc/http")] = zap.NewAtomicLevelAt(zapcore.WarnLevel)
+			},
+			want: []Package{"acme/svc/store", "acme/svc/http"},
+		},
+		{
+			name: "no matches",
+			args: args{
+				pattern: "other/*",
+				level:   zap.DebugLevel,
+			},
+			setup: func() {
+				std.levels = make(map[Package]zap.AtomicLevel)
+				std.levels[Package("acme/svc")] = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+			},
+			wantErr: ErrPkgNotRegistered,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			got, err := SetMatching(tt.args.pattern, tt.args.level, SourceAPI)
+			assert.Equal(t, tt.wantErr, err)
+			assert.ElementsMatch(t, tt.want, got)
+			for _, pkg := range got {
+				assert.Equal(t, tt.args.level, std.levels[pkg].Level())
+			}
+		})
+	}
+}
+
+func TestPackage_Lineage(t *testing.T) {
+	assert.Equal(t, []Package{
+		"github.com/acme/db/sql",
+		"github.com/acme/db",
+		"github.com/acme",
+		"github.com",
+	}, Package("github.com/acme/db/sql").Lineage())
+	assert.Equal(t, []Package{"main"}, Package("main").Lineage())
+}
+
+func TestHierarchy(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	store := Get("acme/svc/store")
+	http := Get("acme/svc/http")
+	other := Get("other")
+
+	// a prefix applies to all descendants
+	assert.NoError(t, Set("acme/svc", zap.DebugLevel, SourceAPI))
+	assert.Equal(t, zap.DebugLevel, store.Level())
+	assert.Equal(t, zap.DebugLevel, http.Level())
+	assert.Equal(t, zap.InfoLevel, other.Level())
+
+	// packages registering later inherit the prefix level
+	late := Get("acme/svc/store/cache")
+	assert.Equal(t, zap.DebugLevel, late.Level())
+
+	// the most specific level wins
+	assert.NoError(t, Set("acme/svc/store", zap.ErrorLevel, SourceAPI))
+	assert.Equal(t, zap.ErrorLevel, store.Level())
+	assert.Equal(t, zap.ErrorLevel, late.Level())
+	assert.Equal(t, zap.DebugLevel, http.Level())
+
+	// the default level does not touch packages with an inherited level
+	SetDefaultLevel(zap.WarnLevel, SourceAPI)
+	assert.Equal(t, zap.WarnLevel, other.Level())
+	assert.Equal(t, zap.DebugLevel, http.Level())
+
+	// clearing falls back to the nearest ancestor
+	assert.NoError(t, Clear("acme/svc/store", SourceAPI))
+	assert.Equal(t, zap.DebugLevel, store.Level())
+	assert.NoError(t, Clear("acme/svc", SourceAPI))
+	assert.Equal(t, zap.WarnLevel, store.Level())
+	assert.Equal(t, ErrNoOverride, Clear("acme/svc", SourceAPI))
+
+	// partial path segments are not prefixes
+	assert.Equal(t, ErrPkgNotRegistered, Set("acme/sv", zap.DebugLevel, SourceAPI))
+}
+
+func TestEntries(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	Get("acme/svc")
+	Get("acme/svc/store")
+	Get("main")
+	assert.NoError(t, Set("acme", zap.DebugLevel, SourceAPI))
+	assert.NoError(t, Set("acme/svc", zap.WarnLevel, SourceAPI))
+
+	want := []Entry{
+		{Package: "acme/svc", Effective: zap.WarnLevel, Explicit: true, From: "acme/svc"},
+		{Package: "acme/svc/store", Effective: zap.WarnLevel, Explicit: false, From: "acme/svc"},
+		{Package: "main", Effective: zap.InfoLevel, Explicit: false, From: ""},
+	}
+	assert.Equal(t, want, Entries())
+	assert.Equal(t, map[Package]zapcore.Level{
+		"acme":     zap.DebugLevel,
+		"acme/svc": zap.WarnLevel,
+	}, Overrides())
+}
+
+func TestWatch(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	Get("acme/svc")
+	Get("acme/svc/store")
+	Get("main")
+
+	var events []Event
+	cancel := Watch(func(e Event) {
+		events = append(events, e)
+	})
+
+	assert.NoError(t, Set("acme", zap.DebugLevel, SourceAdmin))
+	assert.ElementsMatch(t, []Event{
+		{Package: "acme/svc", Old: zap.InfoLevel, New: zap.DebugLevel, Target: "acme", Source: SourceAdmin},
+		{Package: "acme/svc/store", Old: zap.InfoLevel, New: zap.DebugLevel, Target: "acme", Source: SourceAdmin},
+	}, events)
+
+	// unchanged effective levels produce no events
+	events = nil
+	SetDefaultLevel(zap.ErrorLevel, SourceConfigure)
+	assert.Equal(t, []Event{
+		{Package: "main", Old: zap.InfoLevel, New: zap.ErrorLevel, Target: "", Source: SourceConfigure},
+	}, events)
+
+	// watchers may query the registry while being notified
+	events = nil
+	Watch(func(e Event) {
+		assert.Equal(t, e.New, Get(e.Package).Level())
+	})
+	assert.NoError(t, Clear("acme", SourceAPI))
+	assert.Len(t, events, 2)
+
+	events = nil
+	cancel()
+	assert.NoError(t, Set("main", zap.DebugLevel, SourceAPI))
+	assert.Empty(t, events)
+}
+
+func TestWatch_order(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	Get("main")
+
+	var mutex sync.Mutex
+	var events []Event
+	Watch(func(e Event) {
+		// give concurrent changes a chance to overtake this one
+		runtime.Gosched()
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, e)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = Set("main", zapcore.Level((i+j)%4-1), SourceAPI)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// events chain in the order the changes were applied
+	assert.NotEmpty(t, events)
+	old := zap.InfoLevel
+	for _, e := range events {
+		assert.Equal(t, old, e.Old)
+		old = e.New
+	}
+	assert.Equal(t, Get("main").Level(), old)
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	Watch(func(e Event) { /* no-op */ })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pkg := Package(fmt.Sprintf("acme/svc/%d", i))
+			for j := 0; j < 100; j++ {
+				Get(pkg)
+				_ = Set("acme/svc", zapcore.Level(j%4-1), SourceAPI)
+				_ = Set(pkg, zap.WarnLevel, SourceAPI)
+				_ = Clear(pkg, SourceAPI)
+				SetDefaultLevel(zap.InfoLevel, SourceAPI)
+				GetPackages()
+				Entries()
+				Overrides()
+				DefaultLevel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, GetPackages(), 8)
+}
+
+func TestDeclare(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	Declare("acme/db", zap.DebugLevel, SourceConfigure)
+	assert.Equal(t, zap.DebugLevel, Get("acme/db/postgres").Level())
+	assert.Equal(t, zap.InfoLevel, Get("acme/dbx").Level())
+	assert.Equal(t, map[Package]zapcore.Level{"acme/db": zap.DebugLevel}, Overrides())
+}
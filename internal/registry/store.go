@@ -0,0 +1,257 @@
+package registry
+
+import (
+	"path"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// store is a synchronized collection of package levels. All reads and
+// writes of its state go through its mutex, while watchers are notified
+// once the mutex has been released so they are free to query the store.
+// Events are queued in the order changes are applied, and delivered in
+// that order one change at a time
+type store struct {
+	mutex        sync.RWMutex
+	levels       map[Package]zap.AtomicLevel
+	overrides    map[Package]zapcore.Level
+	defaultLevel zapcore.Level
+	watchers     map[int]func(Event)
+	nextWatcher  int
+	// queued holds the events yet to be delivered, guarded by mutex
+	queued []Event
+	// delivering serializes the delivery of queued events
+	delivering sync.Mutex
+}
+
+func newStore() *store {
+	return &store{
+		levels:       make(map[Package]zap.AtomicLevel),
+		overrides:    make(map[Package]zapcore.Level),
+		defaultLevel: zapcore.InfoLevel,
+		watchers:     make(map[int]func(Event)),
+	}
+}
+
+// resolve walks up the import path of pkg and returns the level of the
+// nearest explicit override, falling back to the default level. The
+// caller must hold the mutex
+func (s *store) resolve(pkg Package) (zapcore.Level, Package) {
+	for p, ok := pkg, true; ok; p, ok = p.parent() {
+		if lvl, found := s.overrides[p]; found {
+			return lvl, p
+		}
+	}
+	return s.defaultLevel, ""
+}
+
+// propagate applies the effective level to every registered package, returning
+// an event for each package whose level changed. The caller must hold the mutex
+func (s *store) propagate(target Package, source Source) []Event {
+	var events []Event
+	for pkg, lvl := range s.levels {
+		old := lvl.Level()
+		effective, _ := s.resolve(pkg)
+		if old == effective {
+			continue
+		}
+		lvl.SetLevel(effective)
+		events = append(events, Event{
+			Package: pkg,
+			Old:     old,
+			New:     effective,
+			Target:  target,
+			Source:  source,
+		})
+	}
+	return events
+}
+
+// known reports whether pkg is registered or is a prefix of a registered
+// package. The caller must hold the mutex
+func (s *store) known(pkg Package) bool {
+	for p := range s.levels {
+		if pkg.contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// notify queues events, releases the mutex and delivers all queued events to
+// the watchers, including those queued by concurrent changes. Events are delivered
+// in the order they were queued, so that the Old and New levels of consecutive
+// events for a package chain. The caller must hold the mutex
+func (s *store) notify(events []Event) {
+	if len(events) == 0 {
+		s.mutex.Unlock()
+		return
+	}
+	s.queued = append(s.queued, events...)
+	s.mutex.Unlock()
+
+	s.delivering.Lock()
+	defer s.delivering.Unlock()
+
+	for {
+		s.mutex.Lock()
+		queued := s.queued
+		s.queued = nil
+		watchers := make([]func(Event), 0, len(s.watchers))
+		for _, fn := range s.watchers {
+			watchers = append(watchers, fn)
+		}
+		s.mutex.Unlock()
+
+		if len(queued) == 0 {
+			return
+		}
+		for _, event := range queued {
+			for _, fn := range watchers {
+				fn(event)
+			}
+		}
+	}
+}
+
+func (s *store) setDefault(level zapcore.Level, source Source) {
+	s.mutex.Lock()
+	s.defaultLevel = level
+	s.notify(s.propagate("", source))
+}
+
+func (s *store) getDefault() zapcore.Level {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.defaultLevel
+}
+
+func (s *store) set(pkg Package, level zapcore.Level, source Source) error {
+	s.mutex.Lock()
+	if !s.known(pkg) {
+		s.mutex.Unlock()
+		return ErrPkgNotRegistered
+	}
+	s.overrides[pkg] = level
+	s.notify(s.propagate(pkg, source))
+	return nil
+}
+
+func (s *store) declare(pkg Package, level zapcore.Level, source Source) {
+	s.mutex.Lock()
+	s.overrides[pkg] = level
+	s.notify(s.propagate(pkg, source))
+}
+
+func (s *store) setMatching(pattern string, level zapcore.Level, source Source) ([]Package, error) {
+	s.mutex.Lock()
+	var matched []Package
+	for pkg := range s.levels {
+		ok, err := path.Match(pattern, string(pkg))
+		if err != nil {
+			s.mutex.Unlock()
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, pkg)
+		}
+	}
+
+	if len(matched) == 0 {
+		s.mutex.Unlock()
+		return nil, ErrPkgNotRegistered
+	}
+
+	for _, pkg := range matched {
+		s.overrides[pkg] = level
+	}
+	s.notify(s.propagate(Package(pattern), source))
+	return matched, nil
+}
+
+func (s *store) clear(pkg Package, source Source) error {
+	s.mutex.Lock()
+	if _, ok := s.overrides[pkg]; !ok {
+		s.mutex.Unlock()
+		return ErrNoOverride
+	}
+	delete(s.overrides, pkg)
+	s.notify(s.propagate(pkg, source))
+	return nil
+}
+
+func (s *store) get(pkg Package) zap.AtomicLevel {
+	s.mutex.RLock()
+	lvl, ok := s.levels[pkg]
+	s.mutex.RUnlock()
+	if ok {
+		return lvl
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// another goroutine may have registered the package in the meantime
+	lvl, ok = s.levels[pkg]
+	if !ok {
+		effective, _ := s.resolve(pkg)
+		lvl = zap.NewAtomicLevelAt(effective)
+		s.levels[pkg] = lvl
+	}
+	return lvl
+}
+
+func (s *store) packages() []Package {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	pkgs := make([]Package, 0, len(s.levels))
+	for pkg := range s.levels {
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs
+}
+
+func (s *store) entries() []Entry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	entries := make([]Entry, 0, len(s.levels))
+	for pkg, lvl := range s.levels {
+		_, from := s.resolve(pkg)
+		entries = append(entries, Entry{
+			Package:   pkg,
+			Effective: lvl.Level(),
+			Explicit:  from == pkg,
+			From:      from,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Package < entries[j].Package
+	})
+	return entries
+}
+
+func (s *store) explicit() map[Package]zapcore.Level {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := make(map[Package]zapcore.Level, len(s.overrides))
+	for pkg, lvl := range s.overrides {
+		result[pkg] = lvl
+	}
+	return result
+}
+
+func (s *store) watch(fn func(Event)) func() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	id := s.nextWatcher
+	s.nextWatcher++
+	s.watchers[id] = fn
+
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.watchers, id)
+	}
+}
@@ -1,38 +1,133 @@
 package json
 
 import (
+	"github.com/syllabix/logger/encode"
 	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/zapcore"
 )
 
-// Encoder encodes log messages in logstash json format
+// Encoder encodes log messages in logstash json format, or in the
+// layout of another Schema
 type Encoder struct {
 	enc zapcore.Encoder
+	// top writes the entry keys and the entry fields of schemas that lay
+	// them out at the top level, ahead of the context and fields written by
+	// enc. It is nil for other schemas
+	top     zapcore.Encoder
+	schema  Schema
+	project string
+}
+
+var bufferpool = buffer.NewPool()
+
+// An EncoderOption configures an Encoder
+type EncoderOption func(e *Encoder)
+
+// TraceProject sets the Google Cloud project traces belong to. The GCP schema
+// writes the trace of an entry as projects/<project>/traces/<trace id> when it
+// is set, which lets Cloud Logging link entries to Cloud Trace
+func TraceProject(project string) EncoderOption {
+	return func(e *Encoder) {
+		e.project = project
+	}
 }
 
 // Clone implements the zapcore Encoder interface
 func (e *Encoder) Clone() zapcore.Encoder {
 	encoder := e.enc.Clone()
-	return &Encoder{encoder}
+	return &Encoder{enc: encoder, top: e.top, schema: e.schema, project: e.project}
 }
 
 // EncodeEntry encodes the log entry in logstash json format
 func (e *Encoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fields = e.schema.mapFields(fields)
+	if e.top == nil {
+		if extra := e.schema.entryFields(ent); len(extra) > 0 {
+			fields = append(fields, extra...)
+		}
+		return e.enc.EncodeEntry(ent, fields)
+	}
 
-	cfield, defined := caller(ent)
-	if defined {
-		fields = append(fields, cfield)
+	top, err := e.top.EncodeEntry(ent, e.schema.entryFields(ent))
+	if err != nil {
+		return nil, err
+	}
+	rest, err := e.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		top.Free()
+		return nil, err
 	}
+	defer rest.Free()
+
+	// join {<entry keys>}\n and {<context and fields>}<line ending>
+	top.TrimNewline()
+	joined := top.Bytes()[:top.Len()-1]
+	others := rest.Bytes()[1:]
+	entry := bufferpool.Get()
+	entry.Write(joined)
+	if len(joined) > 1 && others[0] != '}' {
+		entry.AppendByte(',')
+	}
+	entry.Write(others)
+	top.Free()
+	return entry, nil
+}
 
-	fields = append(fields, level(ent))
-	return e.enc.EncodeEntry(ent, fields)
+// Schema returns the schema of the encoder
+func (e *Encoder) Schema() Schema {
+	return e.schema
+}
+
+// BaseFields returns the fields every entry written by the encoder should
+// start with, as laid out by its schema
+func (e *Encoder) BaseFields(host, application string) []zapcore.Field {
+	return e.schema.Fields(host, application)
 }
 
 // NewEncoder returns a json Encoder
 func NewEncoder(cfg zapcore.EncoderConfig) *Encoder {
-	return &Encoder{
-		enc: zapcore.NewJSONEncoder(cfg),
+	return NewSchemaEncoder(cfg, Legacy)
+}
+
+// NewSchemaEncoder returns a json Encoder writing entries in the layout of
+// schema. The keys of cfg are typically those of schema.Config()
+func NewSchemaEncoder(cfg zapcore.EncoderConfig, schema Schema, options ...EncoderOption) *Encoder {
+	e := &Encoder{
+		enc:    zapcore.NewJSONEncoder(cfg),
+		schema: schema,
+	}
+	if schema.topLevel() {
+		// the entry keys are written by top, and the stacktrace after the
+		// fields as usual
+		top := cfg
+		top.StacktraceKey = ""
+		top.LineEnding = "\n"
+		e.top = zapcore.NewJSONEncoder(top)
+
+		rest := cfg
+		rest.TimeKey = ""
+		rest.LevelKey = ""
+		rest.NameKey = ""
+		rest.CallerKey = ""
+		rest.FunctionKey = ""
+		rest.MessageKey = ""
+		e.enc = zapcore.NewJSONEncoder(rest)
+	}
+	for _, opt := range options {
+		opt(e)
+	}
+	return e
+}
+
+// addTrace writes the ids of a trace with the keys of the schema
+func (e *Encoder) addTrace(t encode.Trace) {
+	traceKey, spanKey := e.schema.traceKeys()
+	traceID := t.TraceID
+	if e.schema == GCP && len(e.project) > 0 {
+		traceID = "projects/" + e.project + "/traces/" + traceID
 	}
+	e.enc.AddString(traceKey, traceID)
+	e.enc.AddString(spanKey, t.SpanID)
 }
 
 func level(ent zapcore.Entry) zapcore.Field {
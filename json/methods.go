@@ -3,6 +3,7 @@ package json
 import (
 	"time"
 
+	"github.com/syllabix/logger/encode"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -10,7 +11,13 @@ func (e *Encoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
 	return e.enc.AddArray(key, marshaler)
 }
 
+// AddObject adds an object to the encoder. A trace is written as top level
+// trace and span id fields when added before the @fields namespace
 func (e *Encoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	if t, ok := marshaler.(encode.Trace); ok {
+		e.addTrace(t)
+		return nil
+	}
 	return e.enc.AddObject(key, marshaler)
 }
 
@@ -67,7 +74,7 @@ func (e *Encoder) AddInt8(key string, value int8) {
 }
 
 func (e *Encoder) AddString(key string, value string) {
-	e.enc.AddString(key, value)
+	e.enc.AddString(e.schema.mapKey(key), value)
 }
 
 func (e *Encoder) AddTime(key string, value time.Time) {
@@ -0,0 +1,287 @@
+package json
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/syllabix/logger/encode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Schema is the layout of the entries written by an Encoder: the keys and types
+// of the entry fields, where context fields are nested and the fields every
+// entry starts with
+type Schema int8
+
+// Supported schemas
+const (
+	// Legacy is the original layout of this module: @message and @timestamp
+	// keys, the host in @source_host, and context fields, the caller and the
+	// level nested under @fields
+	Legacy Schema = iota
+	// LogstashV1 is the logstash json_event v1 layout: @timestamp, @version,
+	// message and host keys, with context fields nested under @fields
+	LogstashV1
+	// ECS is the Elastic Common Schema: @timestamp, log.level, message,
+	// host.hostname, service.name and error.stack_trace keys among others, with
+	// context fields at the top level
+	ECS
+	// Flat writes every field at the top level, with short keys
+	Flat
+	// GCP is the structured json understood by Google Cloud Logging: a severity
+	// with Cloud Logging names, and the source location, trace and labels in
+	// their logging.googleapis.com fields, with context fields at the top level
+	GCP
+	// CloudWatch is the Lambda json log format of AWS CloudWatch Logs:
+	// timestamp, level, message and stackTrace keys, with context fields at
+	// the top level
+	CloudWatch
+)
+
+// ecsVersion is the version of the Elastic Common Schema written by ECS
+const ecsVersion = "1.6.0"
+
+// String returns the name of the schema
+func (s Schema) String() string {
+	switch s {
+	case Legacy:
+		return "legacy"
+	case LogstashV1:
+		return "logstash"
+	case ECS:
+		return "ecs"
+	case Flat:
+		return "flat"
+	case GCP:
+		return "gcp"
+	case CloudWatch:
+		return "cloudwatch"
+	default:
+		return "Schema(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// Config returns the encoder config with the entry keys of the schema
+func (s Schema) Config() zapcore.EncoderConfig {
+	switch s {
+	case LogstashV1:
+		return zapcore.EncoderConfig{
+			TimeKey:        "@timestamp",
+			MessageKey:     "message",
+			LevelKey:       "level",
+			NameKey:        "logger_name",
+			CallerKey:      "caller",
+			StacktraceKey:  "stack_trace",
+			EncodeLevel:    zapcore.CapitalLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+			EncodeDuration: zapcore.SecondsDurationEncoder,
+		}
+	case ECS:
+		// the caller is written as log.origin fields by the encoder
+		return zapcore.EncoderConfig{
+			TimeKey:        "@timestamp",
+			MessageKey:     "message",
+			LevelKey:       "log.level",
+			NameKey:        "log.logger",
+			StacktraceKey:  "error.stack_trace",
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.NanosDurationEncoder,
+		}
+	case Flat:
+		return zapcore.EncoderConfig{
+			TimeKey:        "time",
+			MessageKey:     "message",
+			LevelKey:       "level",
+			NameKey:        "logger",
+			CallerKey:      "caller",
+			StacktraceKey:  "stacktrace",
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+			EncodeDuration: zapcore.SecondsDurationEncoder,
+		}
+	case GCP:
+		return encode.GCPConfig
+	case CloudWatch:
+		return encode.CloudWatchConfig
+	default:
+		return encode.JSONConfig
+	}
+}
+
+// Fields returns the fields every entry of the schema starts with, describing
+// the host and application the entry was logged by. The application is left
+// out when it is empty
+func (s Schema) Fields(host, application string) []zapcore.Field {
+	var fields []zapcore.Field
+	switch s {
+	case LogstashV1:
+		fields = []zapcore.Field{
+			zap.String("@version", "1"),
+			zap.String("host", host),
+		}
+		if len(application) > 0 {
+			fields = append(fields, zap.String("application", application))
+		}
+		return append(fields, zap.Namespace("@fields"))
+	case ECS:
+		fields = []zapcore.Field{
+			zap.String("ecs.version", ecsVersion),
+			zap.String("host.hostname", host),
+		}
+		if len(application) > 0 {
+			fields = append(fields, zap.String("service.name", application))
+		}
+		return fields
+	case Flat:
+		fields = []zapcore.Field{zap.String("host", host)}
+		if len(application) > 0 {
+			fields = append(fields, zap.String("application", application))
+		}
+		return fields
+	case GCP:
+		// labels are indexed by Cloud Logging, unlike the json payload
+		labels := zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("host", host)
+			if len(application) > 0 {
+				enc.AddString("application", application)
+			}
+			return nil
+		})
+		return []zapcore.Field{zap.Object(encode.GCPLabelsKey, labels)}
+	case CloudWatch:
+		fields = []zapcore.Field{zap.String("host", host)}
+		if len(application) > 0 {
+			fields = append(fields, zap.String("service", application))
+		}
+		return fields
+	default:
+		fields = []zapcore.Field{
+			zap.String("@source_host", host),
+			zap.Namespace("@fields"),
+		}
+		if len(application) > 0 {
+			fields = append(fields, zap.String("application", application))
+		}
+		return fields
+	}
+}
+
+// traceKeys returns the keys of the trace and span ids
+func (s Schema) traceKeys() (traceID, spanID string) {
+	switch s {
+	case ECS:
+		return "trace.id", "span.id"
+	case GCP:
+		return encode.GCPTraceKey, encode.GCPSpanIDKey
+	default:
+		return encode.TraceIDKey, encode.SpanIDKey
+	}
+}
+
+// topLevel reports whether the entry fields of the schema are written at the
+// top level, ahead of the context, rather than after the fields of an entry
+func (s Schema) topLevel() bool {
+	return s == ECS || s == GCP
+}
+
+// errorKey is the key of the error field added by zap.Error
+const errorKey = "error"
+
+// mapKey returns the key a field is written with in the schema. ECS writes
+// error fields as the message of its error object
+func (s Schema) mapKey(key string) string {
+	if s == ECS && key == errorKey {
+		return "error.message"
+	}
+	return key
+}
+
+// mapFields returns the fields of an entry as laid out by the schema, with the
+// errors added by zap.Error written as their message in ECS
+func (s Schema) mapFields(fields []zapcore.Field) []zapcore.Field {
+	if s != ECS {
+		return fields
+	}
+
+	var mapped []zapcore.Field
+	for i, f := range fields {
+		if f.Key != errorKey || (f.Type != zapcore.ErrorType && f.Type != zapcore.StringType) {
+			continue
+		}
+		if mapped == nil {
+			mapped = append([]zapcore.Field(nil), fields...)
+		}
+		if err, ok := f.Interface.(error); ok && f.Type == zapcore.ErrorType {
+			mapped[i] = zap.String(s.mapKey(f.Key), err.Error())
+		} else {
+			mapped[i].Key = s.mapKey(f.Key)
+		}
+	}
+	if mapped == nil {
+		return fields
+	}
+	return mapped
+}
+
+// entryFields returns the fields the schema adds to the entry keys of ent,
+// ahead of the context for top level schemas and after the fields otherwise
+func (s Schema) entryFields(ent zapcore.Entry) []zapcore.Field {
+	switch s {
+	case Legacy:
+		var fields []zapcore.Field
+		if cfield, defined := caller(ent); defined {
+			fields = append(fields, cfield)
+		}
+		return append(fields, level(ent))
+	case ECS:
+		if !ent.Caller.Defined {
+			return nil
+		}
+		fields := []zapcore.Field{
+			zap.String("log.origin.file.name", trimmedFile(ent.Caller.File)),
+			zap.Int("log.origin.file.line", ent.Caller.Line),
+		}
+		if len(ent.Caller.Function) > 0 {
+			fields = append(fields, zap.String("log.origin.function", ent.Caller.Function))
+		}
+		return fields
+	case GCP:
+		if !ent.Caller.Defined {
+			return nil
+		}
+		return []zapcore.Field{zap.Object(encode.GCPSourceLocationKey, sourceLocation(ent.Caller))}
+	default:
+		return nil
+	}
+}
+
+// sourceLocation is the source location of a Cloud Logging entry, in which the
+// line is a string as in the json form of the LogEntrySourceLocation message
+type sourceLocation zapcore.EntryCaller
+
+// MarshalLogObject implements the zapcore ObjectMarshaler interface
+func (l sourceLocation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("file", trimmedFile(l.File))
+	enc.AddString("line", strconv.Itoa(l.Line))
+	if len(l.Function) > 0 {
+		enc.AddString("function", l.Function)
+	}
+	return nil
+}
+
+// trimmedFile returns the package directory and file name of a caller, like
+// the short caller encoder does, but without the line number
+func trimmedFile(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i < 0 {
+		return file
+	}
+	if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+		return file[j+1:]
+	}
+	return file
+}
@@ -0,0 +1,242 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/encode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func encodeEntry(t *testing.T, schema Schema, options ...EncoderOption) map[string]interface{} {
+	enc := NewSchemaEncoder(schema.Config(), schema, options...)
+	enc.AddObject(encode.TraceKey, encode.Trace{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"})
+	for _, f := range enc.BaseFields("box", "api") {
+		f.AddTo(enc)
+	}
+
+	ent := zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    time.Date(2020, 11, 5, 10, 30, 0, 0, time.UTC),
+		Message: "hello",
+		Caller:  zapcore.NewEntryCaller(0, "/src/github.com/acme/api/server.go", 42, true),
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.String("user", "gopher")})
+	assert.NoError(t, err)
+
+	var out map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &out), buf.String())
+	return out
+}
+
+func TestSchema(t *testing.T) {
+	trace := "4bf92f3577b34da6a3ce929d0e0e4736"
+	span := "00f067aa0ba902b7"
+
+	tests := []struct {
+		schema Schema
+		want   map[string]interface{}
+	}{
+		{
+			schema: Legacy,
+			want: map[string]interface{}{
+				"@timestamp":   "2020-11-05T10:30:00.000Z",
+				"@message":     "hello",
+				"level":        "WARN",
+				"caller":       "api/server.go:42",
+				"trace_id":     trace,
+				"span_id":      span,
+				"@source_host": "box",
+				"@fields": map[string]interface{}{
+					"application": "api",
+					"user":        "gopher",
+					"caller":      "/src/github.com/acme/api/server.go:42",
+					"level":       "warn",
+				},
+			},
+		},
+		{
+			schema: LogstashV1,
+			want: map[string]interface{}{
+				"@timestamp":  "2020-11-05T10:30:00.000Z",
+				"@version":    "1",
+				"message":     "hello",
+				"level":       "WARN",
+				"caller":      "api/server.go:42",
+				"trace_id":    trace,
+				"span_id":     span,
+				"host":        "box",
+				"application": "api",
+				"@fields": map[string]interface{}{
+					"user": "gopher",
+				},
+			},
+		},
+		{
+			schema: ECS,
+			want: map[string]interface{}{
+				"@timestamp":           "2020-11-05T10:30:00.000Z",
+				"message":              "hello",
+				"log.level":            "warn",
+				"ecs.version":          ecsVersion,
+				"trace.id":             trace,
+				"span.id":              span,
+				"host.hostname":        "box",
+				"service.name":         "api",
+				"user":                 "gopher",
+				"log.origin.file.name": "api/server.go",
+				"log.origin.file.line": float64(42),
+			},
+		},
+		{
+			schema: Flat,
+			want: map[string]interface{}{
+				"time":        "2020-11-05T10:30:00.000Z",
+				"message":     "hello",
+				"level":       "warn",
+				"caller":      "api/server.go:42",
+				"trace_id":    trace,
+				"span_id":     span,
+				"host":        "box",
+				"application": "api",
+				"user":        "gopher",
+			},
+		},
+		{
+			schema: GCP,
+			want: map[string]interface{}{
+				"time":                          "2020-11-05T10:30:00Z",
+				"message":                       "hello",
+				"severity":                      "WARNING",
+				"logging.googleapis.com/trace":  trace,
+				"logging.googleapis.com/spanId": span,
+				"logging.googleapis.com/labels": map[string]interface{}{
+					"host":        "box",
+					"application": "api",
+				},
+				"user": "gopher",
+				"logging.googleapis.com/sourceLocation": map[string]interface{}{
+					"file": "api/server.go",
+					"line": "42",
+				},
+			},
+		},
+		{
+			schema: CloudWatch,
+			want: map[string]interface{}{
+				"timestamp": "2020-11-05T10:30:00Z",
+				"message":   "hello",
+				"level":     "WARN",
+				"caller":    "api/server.go:42",
+				"trace_id":  trace,
+				"span_id":   span,
+				"host":      "box",
+				"service":   "api",
+				"user":      "gopher",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema.String(), func(t *testing.T) {
+			got := encodeEntry(t, tt.schema)
+			if _, ok := got["@timestamp"]; ok {
+				// the timestamp is written in the local time zone
+				got["@timestamp"] = tt.want["@timestamp"]
+			}
+			for _, key := range []string{"time", "timestamp"} {
+				if _, ok := got[key]; ok {
+					got[key] = tt.want[key]
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// encodeRaw encodes an entry logged with a namespace opened in the context
+func encodeRaw(t *testing.T, schema Schema, fields ...zapcore.Field) string {
+	enc := NewSchemaEncoder(schema.Config(), schema)
+	zap.String("app", "api").AddTo(enc)
+	zap.Namespace("http").AddTo(enc)
+
+	ent := zapcore.Entry{
+		Level:   zapcore.ErrorLevel,
+		Time:    time.Date(2020, 11, 5, 10, 30, 0, 0, time.UTC),
+		Message: "failed",
+		Caller:  zapcore.EntryCaller{Defined: true, File: "/src/github.com/acme/api/server.go", Line: 42, Function: "main.serve"},
+	}
+	buf, err := enc.EncodeEntry(ent, fields)
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(buf.Bytes()), buf.String())
+	return buf.String()
+}
+
+func TestSchema_ECSNamespaces(t *testing.T) {
+	got := encodeRaw(t, ECS, zap.Int("status", 500))
+	assert.Equal(t, `{"log.level":"error","@timestamp":"2020-11-05T10:30:00.000Z","message":"failed",`+
+		`"log.origin.file.name":"api/server.go","log.origin.file.line":42,"log.origin.function":"main.serve",`+
+		`"app":"api","http":{"status":500}}`+"\n", got)
+}
+
+func TestSchema_GCPNamespaces(t *testing.T) {
+	got := encodeRaw(t, GCP, zap.Int("status", 500))
+	assert.Equal(t, `{"severity":"ERROR","time":"2020-11-05T10:30:00Z","message":"failed",`+
+		`"logging.googleapis.com/sourceLocation":{"file":"api/server.go","line":"42","function":"main.serve"},`+
+		`"app":"api","http":{"status":500}}`+"\n", got)
+}
+
+func TestSchema_ECSErrors(t *testing.T) {
+	got := encodeRaw(t, ECS, zap.Error(errors.New("boom")))
+	assert.Contains(t, got, `"http":{"error.message":"boom"}`)
+
+	// errors in the context are mapped as well
+	enc := NewSchemaEncoder(ECS.Config(), ECS)
+	zap.Error(errors.New("context")).AddTo(enc)
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: "failed"}, []zapcore.Field{zap.NamedError("cause", errors.New("other"))})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"error.message":"context","cause":"other"}`)
+	assert.NotContains(t, buf.String(), `"error":`)
+}
+
+func TestTraceProject(t *testing.T) {
+	got := encodeEntry(t, GCP, TraceProject("acme"))
+	assert.Equal(t, "projects/acme/traces/4bf92f3577b34da6a3ce929d0e0e4736", got["logging.googleapis.com/trace"])
+
+	// only the GCP schema qualifies traces with the project
+	got = encodeEntry(t, Flat, TraceProject("acme"))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got["trace_id"])
+
+	enc := NewSchemaEncoder(GCP.Config(), GCP, TraceProject("acme"))
+	assert.Equal(t, enc, enc.Clone())
+}
+
+func TestSchema_String(t *testing.T) {
+	assert.Equal(t, "ecs", ECS.String())
+	assert.Equal(t, "Schema(12)", Schema(12).String())
+}
+
+func TestSchema_Fields(t *testing.T) {
+	for _, schema := range []Schema{Legacy, LogstashV1, ECS, Flat, GCP, CloudWatch} {
+		for _, f := range schema.Fields("box", "") {
+			assert.NotContains(t, []string{"application", "service.name"}, f.Key, schema.String())
+		}
+	}
+}
+
+func TestTrimmedFile(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{file: "/src/github.com/acme/api/server.go", want: "api/server.go"},
+		{file: "api/server.go", want: "api/server.go"},
+		{file: "server.go", want: "server.go"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, trimmedFile(tt.file))
+	}
+}
@@ -0,0 +1,166 @@
+// Package logfmt provides a zap encoder writing entries as logfmt lines, such
+// as those parsed by Loki and Promtail.
+//
+// Every line starts with the entry keys in a fixed order: time, level, logger
+// name, caller and message, followed by the context fields in the order they
+// were added and the stacktrace. Values with spaces, quotes, equal signs or
+// control characters are quoted and escaped. Nested objects, arrays and
+// namespaces are flattened into dotted keys, such as user.roles.0=admin, and
+// the keys of reflected maps and structs are sorted, so equal entries are
+// always written the same way
+package logfmt
+
+import (
+	"time"
+
+	"github.com/syllabix/logger/encode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var bufferpool = buffer.NewPool()
+
+// Encoder is a zap encoder writing entries in logfmt
+type Encoder struct {
+	config *zapcore.EncoderConfig
+	buf    *buffer.Buffer
+	// prefix is the dotted path of the current namespace and object, with
+	// a trailing dot
+	prefix string
+}
+
+// NewEncoder returns a logfmt Encoder. Entry keys that are empty in cfg are
+// left out, and encode.LogfmtConfig is a good default
+func NewEncoder(cfg zapcore.EncoderConfig) *Encoder {
+	return &Encoder{
+		config: &cfg,
+		buf:    bufferpool.Get(),
+	}
+}
+
+// Register is used to register the logfmt Encoder to the zap framework
+func Register(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	return NewEncoder(cfg), nil
+}
+
+// Clone implements the Clone method of the zapcore Encoder interface
+func (e *Encoder) Clone() zapcore.Encoder {
+	clone := e.clone()
+	clone.buf.Write(e.buf.Bytes())
+	return clone
+}
+
+func (e *Encoder) clone() *Encoder {
+	return &Encoder{
+		config: e.config,
+		buf:    bufferpool.Get(),
+		prefix: e.prefix,
+	}
+}
+
+// BaseFields returns the fields every entry starts with. Unlike the json
+// encoder, these are not followed by a namespace
+func (e *Encoder) BaseFields(host, application string) []zapcore.Field {
+	fields := []zapcore.Field{zap.String("host", host)}
+	if len(application) > 0 {
+		fields = append(fields, zap.String("application", application))
+	}
+	return fields
+}
+
+// EncodeEntry implements the EncodeEntry method of the zapcore Encoder interface
+func (e *Encoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.clone()
+	config := final.config
+
+	if len(config.TimeKey) > 0 {
+		final.addEncoded(config.TimeKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			if config.EncodeTime != nil {
+				config.EncodeTime(ent.Time, enc)
+			}
+		}, ent.Time.Format(time.RFC3339Nano))
+	}
+
+	if len(config.LevelKey) > 0 {
+		final.addEncoded(config.LevelKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			if config.EncodeLevel != nil {
+				config.EncodeLevel(ent.Level, enc)
+			}
+		}, ent.Level.String())
+	}
+
+	if len(ent.LoggerName) > 0 && len(config.NameKey) > 0 {
+		final.addEncoded(config.NameKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			if config.EncodeName != nil {
+				config.EncodeName(ent.LoggerName, enc)
+			}
+		}, ent.LoggerName)
+	}
+
+	if ent.Caller.Defined && len(config.CallerKey) > 0 {
+		final.addEncoded(config.CallerKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			if config.EncodeCaller != nil {
+				config.EncodeCaller(ent.Caller, enc)
+			}
+		}, ent.Caller.String())
+	}
+
+	if len(config.MessageKey) > 0 {
+		final.appendKey(config.MessageKey)
+		final.appendValue(ent.Message)
+	}
+
+	if e.buf.Len() > 0 {
+		if final.buf.Len() > 0 {
+			final.buf.AppendByte(' ')
+		}
+		final.buf.Write(e.buf.Bytes())
+	}
+
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+
+	if len(ent.Stack) > 0 && len(config.StacktraceKey) > 0 {
+		final.appendKey(config.StacktraceKey)
+		final.appendValue(ent.Stack)
+	}
+
+	if len(config.LineEnding) > 0 {
+		final.buf.AppendString(config.LineEnding)
+	} else {
+		final.buf.AppendString(zapcore.DefaultLineEnding)
+	}
+
+	return final.buf, nil
+}
+
+// addKey writes the separator and the key of a field, prefixed with the
+// current namespace and object path
+func (e *Encoder) addKey(key string) {
+	e.appendKey(e.prefix + key)
+}
+
+// appendKey writes the separator and key as is
+func (e *Encoder) appendKey(key string) {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+	encode.AppendKey(e.buf, key)
+	e.buf.AppendByte('=')
+}
+
+// appendValue writes a string value, quoting it when needed
+func (e *Encoder) appendValue(val string) {
+	appendValue(e.buf, val)
+}
+
+// addEncoded writes the value that fn appends for an entry key, or fallback
+// if it appends nothing. Entry keys are never prefixed
+func (e *Encoder) addEncoded(key string, fn func(zapcore.PrimitiveArrayEncoder), fallback string) {
+	var p primitives
+	fn(&p)
+	e.appendKey(key)
+	e.appendValue(p.String(fallback))
+}
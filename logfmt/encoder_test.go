@@ -0,0 +1,131 @@
+package logfmt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/encode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type user struct {
+	Name  string
+	Roles []string
+}
+
+func (u user) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	return enc.AddArray("roles", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, r := range u.Roles {
+			arr.AppendString(r)
+		}
+		return nil
+	}))
+}
+
+var entry = zapcore.Entry{
+	Level:   zapcore.WarnLevel,
+	Time:    time.Date(2020, 11, 5, 10, 30, 0, 0, time.UTC),
+	Message: "user signed in",
+	Caller:  zapcore.NewEntryCaller(0, "/src/github.com/acme/api/server.go", 42, true),
+}
+
+func TestEncoder_EncodeEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		context []zapcore.Field
+		fields  []zapcore.Field
+		entry   func(ent zapcore.Entry) zapcore.Entry
+		want    string
+	}{
+		{
+			name: "entry keys",
+			want: `time=2020-11-05T10:30:00Z level=warn caller=api/server.go:42 msg="user signed in"` + "\n",
+		},
+		{
+			name:   "quoting",
+			fields: []zapcore.Field{zap.String("query", `a="b c"`), zap.String("path", `C:\tmp`), zap.String("empty", "")},
+			want:   `time=2020-11-05T10:30:00Z level=warn caller=api/server.go:42 msg="user signed in" query="a=\"b c\"" path="C:\\tmp" empty=` + "\n",
+		},
+		{
+			name:    "context before fields",
+			context: []zapcore.Field{zap.String("request_id", "abc")},
+			fields:  []zapcore.Field{zap.Int("status", 200), zap.Bool("cached", true)},
+			want:    `time=2020-11-05T10:30:00Z level=warn caller=api/server.go:42 msg="user signed in" request_id=abc status=200 cached=true` + "\n",
+		},
+		{
+			name:   "nested objects and arrays",
+			fields: []zapcore.Field{zap.Object("user", user{Name: "gopher", Roles: []string{"admin", "dev"}}), zap.Strings("tags", nil)},
+			want:   `time=2020-11-05T10:30:00Z level=warn caller=api/server.go:42 msg="user signed in" user.name=gopher user.roles.0=admin user.roles.1=dev tags=` + "\n",
+		},
+		{
+			name:    "namespaces",
+			context: []zapcore.Field{zap.Namespace("http"), zap.String("method", "GET")},
+			fields:  []zapcore.Field{zap.Int("status", 404)},
+			want:    `time=2020-11-05T10:30:00Z level=warn caller=api/server.go:42 msg="user signed in" http.method=GET http.status=404` + "\n",
+		},
+		{
+			name:   "reflected values are sorted",
+			fields: []zapcore.Field{zap.Any("labels", map[string]interface{}{"zone": "b", "app": "api", "replicas": 3, "extra": nil})},
+			want:   `time=2020-11-05T10:30:00Z level=warn caller=api/server.go:42 msg="user signed in" labels.app=api labels.extra= labels.replicas=3 labels.zone=b` + "\n",
+		},
+		{
+			name:   "errors, durations and traces",
+			fields: []zapcore.Field{zap.Error(errors.New("not found")), zap.Duration("took", 1500*time.Millisecond), encode.TraceField(encode.Trace{TraceID: "4bf9", SpanID: "00f0"})},
+			want:   `time=2020-11-05T10:30:00Z level=warn caller=api/server.go:42 msg="user signed in" error="not found" took=1.5s trace_id=4bf9 span_id=00f0` + "\n",
+		},
+		{
+			name: "name and stacktrace",
+			entry: func(ent zapcore.Entry) zapcore.Entry {
+				ent.LoggerName = "api"
+				ent.Caller = zapcore.EntryCaller{}
+				ent.Stack = "main.main\n\t/src/main.go:10"
+				return ent
+			},
+			want: `time=2020-11-05T10:30:00Z level=warn logger=api msg="user signed in" stacktrace="main.main\n\t/src/main.go:10"` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := NewEncoder(encode.LogfmtConfig)
+			for _, f := range tt.context {
+				f.AddTo(enc)
+			}
+
+			ent := entry
+			if tt.entry != nil {
+				ent = tt.entry(ent)
+			}
+			buf, err := enc.Clone().EncodeEntry(ent, tt.fields)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestEncoder_Clone(t *testing.T) {
+	enc := NewEncoder(encode.LogfmtConfig)
+	enc.OpenNamespace("http")
+	enc.AddString("method", "GET")
+
+	clone := enc.Clone()
+	clone.AddString("path", "/users")
+	enc.AddString("other", "field")
+
+	buf, err := clone.EncodeEntry(zapcore.Entry{Message: "hello"}, []zapcore.Field{zap.Int("status", 200)})
+	assert.NoError(t, err)
+	assert.Equal(t, `time=0001-01-01T00:00:00Z level=info msg=hello http.method=GET http.path=/users http.status=200`+"\n", buf.String())
+}
+
+func TestEncoder_BaseFields(t *testing.T) {
+	enc := NewEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	for _, f := range enc.BaseFields("box", "api") {
+		f.AddTo(enc)
+	}
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: "hello"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "msg=hello host=box application=api\n", buf.String())
+}
@@ -0,0 +1,132 @@
+package logfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/syllabix/logger/encode"
+	"go.uber.org/zap/buffer"
+)
+
+// appendValue writes val, quoted and escaped when it is not a valid bare value
+func appendValue(buf *buffer.Buffer, val string) {
+	if !encode.NeedsQuoting(val) {
+		buf.AppendString(val)
+		return
+	}
+	encode.AppendQuoted(buf, val)
+}
+
+// addReflected flattens a value of any type into dotted keys. Values are
+// converted as they would be by encoding/json, falling back to fmt
+func (e *Encoder) addReflected(key string, val interface{}) {
+	switch v := val.(type) {
+	case string:
+		e.AddString(key, v)
+		return
+	case error:
+		e.AddString(key, v.Error())
+		return
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		e.AddString(key, fmt.Sprintf("%+v", val))
+		return
+	}
+
+	var decoded interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		e.AddString(key, string(data))
+		return
+	}
+	e.addFlattened(key, decoded)
+}
+
+// addFlattened writes a decoded json value, with the keys of objects in
+// sorted order
+func (e *Encoder) addFlattened(key string, val interface{}) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			e.addKey(key)
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e.addFlattened(key+"."+k, v[k])
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			e.addKey(key)
+			return
+		}
+		for i, elem := range v {
+			e.addFlattened(key+"."+strconv.Itoa(i), elem)
+		}
+	case string:
+		e.AddString(key, v)
+	case json.Number:
+		e.addKey(key)
+		e.buf.AppendString(v.String())
+	case bool:
+		e.AddBool(key, v)
+	default:
+		// null
+		e.addKey(key)
+	}
+}
+
+// primitives collects the values appended by an entry encoder, such as a
+// time or level encoder
+type primitives struct {
+	values []string
+}
+
+// String returns the collected values separated by spaces, or fallback when
+// none were appended
+func (p *primitives) String(fallback string) string {
+	if len(p.values) == 0 {
+		return fallback
+	}
+	return strings.Join(p.values, " ")
+}
+
+func (p *primitives) append(val string) {
+	p.values = append(p.values, val)
+}
+
+func (p *primitives) AppendBool(val bool)         { p.append(strconv.FormatBool(val)) }
+func (p *primitives) AppendByteString(val []byte) { p.append(string(val)) }
+func (p *primitives) AppendComplex128(val complex128) {
+	p.append(strconv.FormatComplex(val, 'g', -1, 128))
+}
+func (p *primitives) AppendComplex64(val complex64) {
+	p.append(strconv.FormatComplex(complex128(val), 'g', -1, 64))
+}
+func (p *primitives) AppendFloat64(val float64) { p.append(strconv.FormatFloat(val, 'g', -1, 64)) }
+func (p *primitives) AppendFloat32(val float32) {
+	p.append(strconv.FormatFloat(float64(val), 'g', -1, 32))
+}
+func (p *primitives) AppendInt(val int)         { p.append(strconv.FormatInt(int64(val), 10)) }
+func (p *primitives) AppendInt64(val int64)     { p.append(strconv.FormatInt(val, 10)) }
+func (p *primitives) AppendInt32(val int32)     { p.append(strconv.FormatInt(int64(val), 10)) }
+func (p *primitives) AppendInt16(val int16)     { p.append(strconv.FormatInt(int64(val), 10)) }
+func (p *primitives) AppendInt8(val int8)       { p.append(strconv.FormatInt(int64(val), 10)) }
+func (p *primitives) AppendString(val string)   { p.append(val) }
+func (p *primitives) AppendUint(val uint)       { p.append(strconv.FormatUint(uint64(val), 10)) }
+func (p *primitives) AppendUint64(val uint64)   { p.append(strconv.FormatUint(val, 10)) }
+func (p *primitives) AppendUint32(val uint32)   { p.append(strconv.FormatUint(uint64(val), 10)) }
+func (p *primitives) AppendUint16(val uint16)   { p.append(strconv.FormatUint(uint64(val), 10)) }
+func (p *primitives) AppendUint8(val uint8)     { p.append(strconv.FormatUint(uint64(val), 10)) }
+func (p *primitives) AppendUintptr(val uintptr) { p.append(strconv.FormatUint(uint64(val), 10)) }
@@ -0,0 +1,47 @@
+package logfmt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/buffer"
+)
+
+func TestAppendValue(t *testing.T) {
+	tests := []struct {
+		val  string
+		want string
+	}{
+		{val: "plain", want: "plain"},
+		{val: "", want: ""},
+		{val: "/users/42?verbose=true", want: `"/users/42?verbose=true"`},
+		{val: "two words", want: `"two words"`},
+		{val: `say "hi"`, want: `"say \"hi\""`},
+		{val: `back\slash`, want: `"back\\slash"`},
+		{val: "line\nbreak\ttab\rreturn", want: `"line\nbreak\ttab\rreturn"`},
+		{val: "bell\x07", want: `"bell\u0007"`},
+		{val: "\x1b[31mred\x1b[0m", want: `"\u001b[31mred\u001b[0m"`},
+		{val: "del\x7f", want: `"del\u007f"`},
+		{val: "invalid\xff", want: `"invalid\ufffd"`},
+		{val: "zero\u200bwidth", want: `"zero\u200bwidth"`},
+		{val: "héllo", want: "héllo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			buf := new(buffer.Buffer)
+			appendValue(buf, tt.val)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestPrimitives_String(t *testing.T) {
+	var p primitives
+	assert.Equal(t, "fallback", p.String("fallback"))
+
+	p.AppendString("a")
+	p.AppendInt64(1)
+	p.AppendFloat64(1.5)
+	p.AppendBool(true)
+	assert.Equal(t, "a 1 1.5 true", p.String("fallback"))
+}
@@ -0,0 +1,198 @@
+package logfmt
+
+import (
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/syllabix/logger/encode"
+	"go.uber.org/zap/zapcore"
+)
+
+// This file contains the methods that implement
+// the zapcore ObjectEncoder interface
+
+func (e *Encoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	arr := &arrayEncoder{enc: e, key: key}
+	err := marshaler.MarshalLogArray(arr)
+	if arr.n == 0 {
+		e.addKey(key)
+	}
+	return err
+}
+
+func (e *Encoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	// a trace is written as trace_id and span_id fields, like the json encoder
+	if t, ok := obj.(encode.Trace); ok {
+		e.AddString(encode.TraceIDKey, t.TraceID)
+		e.AddString(encode.SpanIDKey, t.SpanID)
+		return nil
+	}
+
+	prefix, start := e.prefix, e.buf.Len()
+	e.prefix = prefix + key + "."
+	err := obj.MarshalLogObject(e)
+	e.prefix = prefix
+	if e.buf.Len() == start {
+		e.addKey(key)
+	}
+	return err
+}
+
+func (e *Encoder) AddBinary(key string, val []byte) {
+	e.AddString(key, base64.StdEncoding.EncodeToString(val))
+}
+
+func (e *Encoder) AddByteString(key string, val []byte) {
+	e.AddString(key, string(val))
+}
+
+func (e *Encoder) AddBool(key string, val bool) {
+	e.addKey(key)
+	e.buf.AppendBool(val)
+}
+
+func (e *Encoder) AddComplex128(key string, val complex128) {
+	e.addKey(key)
+	e.buf.AppendString(strconv.FormatComplex(val, 'g', -1, 128))
+}
+
+func (e *Encoder) AddComplex64(key string, val complex64) {
+	e.addKey(key)
+	e.buf.AppendString(strconv.FormatComplex(complex128(val), 'g', -1, 64))
+}
+
+func (e *Encoder) AddDuration(key string, val time.Duration) {
+	var p primitives
+	if e.config.EncodeDuration != nil {
+		e.config.EncodeDuration(val, &p)
+	}
+	e.AddString(key, p.String(val.String()))
+}
+
+func (e *Encoder) AddFloat64(key string, val float64) {
+	e.addKey(key)
+	e.buf.AppendFloat(val, 64)
+}
+
+func (e *Encoder) AddFloat32(key string, val float32) {
+	e.addKey(key)
+	e.buf.AppendFloat(float64(val), 32)
+}
+
+func (e *Encoder) AddInt(key string, val int) {
+	e.AddInt64(key, int64(val))
+}
+
+func (e *Encoder) AddInt64(key string, val int64) {
+	e.addKey(key)
+	e.buf.AppendInt(val)
+}
+
+func (e *Encoder) AddInt32(key string, val int32) {
+	e.AddInt64(key, int64(val))
+}
+
+func (e *Encoder) AddInt16(key string, val int16) {
+	e.AddInt64(key, int64(val))
+}
+
+func (e *Encoder) AddInt8(key string, val int8) {
+	e.AddInt64(key, int64(val))
+}
+
+func (e *Encoder) AddString(key string, val string) {
+	e.addKey(key)
+	e.appendValue(val)
+}
+
+func (e *Encoder) AddTime(key string, val time.Time) {
+	var p primitives
+	if e.config.EncodeTime != nil {
+		e.config.EncodeTime(val, &p)
+	}
+	e.AddString(key, p.String(val.Format(time.RFC3339Nano)))
+}
+
+func (e *Encoder) AddUint(key string, val uint) {
+	e.AddUint64(key, uint64(val))
+}
+
+func (e *Encoder) AddUint64(key string, val uint64) {
+	e.addKey(key)
+	e.buf.AppendUint(val)
+}
+
+func (e *Encoder) AddUint32(key string, val uint32) {
+	e.AddUint64(key, uint64(val))
+}
+
+func (e *Encoder) AddUint16(key string, val uint16) {
+	e.AddUint64(key, uint64(val))
+}
+
+func (e *Encoder) AddUint8(key string, val uint8) {
+	e.AddUint64(key, uint64(val))
+}
+
+func (e *Encoder) AddUintptr(key string, val uintptr) {
+	e.AddUint64(key, uint64(val))
+}
+
+func (e *Encoder) AddReflected(key string, val interface{}) error {
+	e.addReflected(key, val)
+	return nil
+}
+
+func (e *Encoder) OpenNamespace(key string) {
+	e.prefix = e.prefix + key + "."
+}
+
+// arrayEncoder writes the elements of an array as fields keyed by their
+// index, such as roles.0=admin roles.1=dev
+type arrayEncoder struct {
+	enc *Encoder
+	key string
+	n   int
+}
+
+// next returns the key of the next element
+func (a *arrayEncoder) next() string {
+	key := a.key + "." + strconv.Itoa(a.n)
+	a.n++
+	return key
+}
+
+func (a *arrayEncoder) AppendBool(val bool)             { a.enc.AddBool(a.next(), val) }
+func (a *arrayEncoder) AppendByteString(val []byte)     { a.enc.AddByteString(a.next(), val) }
+func (a *arrayEncoder) AppendComplex128(val complex128) { a.enc.AddComplex128(a.next(), val) }
+func (a *arrayEncoder) AppendComplex64(val complex64)   { a.enc.AddComplex64(a.next(), val) }
+func (a *arrayEncoder) AppendFloat64(val float64)       { a.enc.AddFloat64(a.next(), val) }
+func (a *arrayEncoder) AppendFloat32(val float32)       { a.enc.AddFloat32(a.next(), val) }
+func (a *arrayEncoder) AppendInt(val int)               { a.enc.AddInt(a.next(), val) }
+func (a *arrayEncoder) AppendInt64(val int64)           { a.enc.AddInt64(a.next(), val) }
+func (a *arrayEncoder) AppendInt32(val int32)           { a.enc.AddInt32(a.next(), val) }
+func (a *arrayEncoder) AppendInt16(val int16)           { a.enc.AddInt16(a.next(), val) }
+func (a *arrayEncoder) AppendInt8(val int8)             { a.enc.AddInt8(a.next(), val) }
+func (a *arrayEncoder) AppendString(val string)         { a.enc.AddString(a.next(), val) }
+func (a *arrayEncoder) AppendUint(val uint)             { a.enc.AddUint(a.next(), val) }
+func (a *arrayEncoder) AppendUint64(val uint64)         { a.enc.AddUint64(a.next(), val) }
+func (a *arrayEncoder) AppendUint32(val uint32)         { a.enc.AddUint32(a.next(), val) }
+func (a *arrayEncoder) AppendUint16(val uint16)         { a.enc.AddUint16(a.next(), val) }
+func (a *arrayEncoder) AppendUint8(val uint8)           { a.enc.AddUint8(a.next(), val) }
+func (a *arrayEncoder) AppendUintptr(val uintptr)       { a.enc.AddUintptr(a.next(), val) }
+func (a *arrayEncoder) AppendDuration(val time.Duration) {
+	a.enc.AddDuration(a.next(), val)
+}
+func (a *arrayEncoder) AppendTime(val time.Time) {
+	a.enc.AddTime(a.next(), val)
+}
+func (a *arrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	return a.enc.AddArray(a.next(), arr)
+}
+func (a *arrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	return a.enc.AddObject(a.next(), obj)
+}
+func (a *arrayEncoder) AppendReflected(val interface{}) error {
+	return a.enc.AddReflected(a.next(), val)
+}
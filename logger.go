@@ -1,78 +1,120 @@
 package logger
 
 import (
+	"io"
+	"runtime/debug"
+
 	"github.com/syllabix/logger/console"
 	"github.com/syllabix/logger/encode"
 	"github.com/syllabix/logger/internal/registry"
 	"github.com/syllabix/logger/json"
-	"github.com/syllabix/logger/mode"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-func consoleConfig() console.Config {
+// consoleConfig returns the config of console encoders writing to w, which
+// color their output depending on the color mode and w
+func consoleConfig(w io.Writer) console.Config {
 	config := console.Config{
-		Mode: global.mode,
+		Mode:       global.mode,
+		Escape:     global.cescape,
+		Namespaces: global.cnamespaces,
+		HideRoot:   !global.cshowroot,
+		Colors:     console.NeverColors,
+		Config:     encode.ProConsoleConfig,
 	}
 
-	if global.mode == mode.Production {
-		config.Config = encode.ProConsoleConfig
-	} else {
+	if global.ccolors.Enabled(w) {
+		config.Colors = console.AlwaysColors
 		config.Config = encode.DevConsoleConfig
+		if global.ctheme != nil {
+			config.Theme = global.ctheme
+			custom := *config.Config
+			custom.EncodeLevel = global.ctheme.ColorLevel
+			config.Config = &custom
+		}
+	}
+
+	if global.cpretty {
+		config.Pretty = true
+		config.Module = mainModule()
+	}
+
+	if !global.keys.isZero() {
+		custom := global.keys.apply(*config.Config)
+		config.Config = &custom
 	}
 	return config
 }
 
-// New returns an instance of a logger configured via the logger package
-// global options
-func New() *zap.Logger {
-
-	level := registry.Get(pkgname())
-
-	// configure console encoder
-	cEncoder := console.NewEncoder(consoleConfig())
-	cout := zapcore.AddSync(global.csink)
-	core := zapcore.NewCore(cEncoder, cout, level)
-
-	// if a json sink has been set, configure it
-	// with the zapcore JSON Encoder, and Tee the console
-	// encoder with it
-	if global.jsink != nil {
-		jEncoder := json.NewEncoder(encode.JSONConfig)
-		rsink := zapcore.AddSync(global.jsink)
-		core = zapcore.NewTee(
-			core,
-			zapcore.NewCore(jEncoder, rsink, level),
-		)
+// mainModule returns the import path of the main module of the binary, or an
+// empty string when it was built without module support
+func mainModule() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
 	}
+	return info.Main.Path
+}
 
-	// TODO: determine the most efficient way to allocate
-	// core = zapcore.NewSampler(
-	// 	core,
-	// 	500*time.Millisecond,
-	// 	10, // first
-	// 	50, // thereafter
-	// )
+func jsonConfig() zapcore.EncoderConfig {
+	return global.keys.apply(global.schema.Config())
+}
 
-	logger := zap.New(core,
-		zap.AddCaller(),
-		zap.AddStacktrace(zap.PanicLevel)).
-		With(zap.String("@source_host", hostname()))
+func logfmtConfig() zapcore.EncoderConfig {
+	return global.keys.apply(encode.LogfmtConfig)
+}
 
-	logger = logger.With(zap.Namespace("@fields"))
+func jsonEncoder() *json.Encoder {
+	return json.NewSchemaEncoder(jsonConfig(), global.schema, json.TraceProject(global.gcpProject))
+}
 
-	if len(global.appname) > 0 {
-		logger = logger.With(zap.String("application", global.appname))
-	}
+// New returns an instance of a logger configured via the logger package
+// global options. Subsequent calls to Configure are applied to the
+// returned logger as well
+func New() *zap.Logger {
+	return newLogger(pkgname())
+}
+
+func newLogger(pkg registry.Package) *zap.Logger {
+	level := registry.Get(pkg)
 
-	return logger
+	return zap.New(newCore(pkg, level),
+		zap.AddCaller(),
+		zap.AddStacktrace(zap.PanicLevel))
 }
 
 // SetLevelForPackage will set the log level for all instances
-// of a logger in the provided package, returning an error if the
-// package name provided does not exist in the registry
+// of a logger in the provided package. The package may also be a prefix
+// of an import path, such as "github.com/acme/svc", in which case the level
+// applies to all descendant packages without a more specific level, including
+// those that create loggers later. An error is returned if the package name
+// provided is neither in the registry nor a prefix of a registered package
 func SetLevelForPackage(pkg string, level zapcore.Level) error {
-	return registry.Set(registry.Package(pkg), level)
+	return registry.Set(registry.Package(pkg), level, registry.SourceAPI)
+}
+
+// ClearLevelForPackage removes the level previously set on the provided package
+// or prefix, so that its loggers fall back to the level of the nearest ancestor,
+// or the default level when no ancestor has one
+func ClearLevelForPackage(pkg string) error {
+	return registry.Clear(registry.Package(pkg), registry.SourceAPI)
+}
+
+// GetPackageLevels returns the effective level of all packages that
+// logger instances have been created in, along with where it was set
+func GetPackageLevels() []PackageLevel {
+	entries := registry.Entries()
+	levels := make([]PackageLevel, len(entries))
+	for i, entry := range entries {
+		levels[i] = PackageLevel{
+			Package:  string(entry.Package),
+			Level:    entry.Effective,
+			Explicit: entry.Explicit,
+			From:     string(entry.From),
+		}
+	}
+	return levels
 }
 
 // GetPackages retuns all package names that logger instances
@@ -85,3 +127,36 @@ func GetPackages() []string {
 	}
 	return strpkgs
 }
+
+// LevelEvent describes a change to the effective log level of a package
+type LevelEvent struct {
+	Package string
+	Old     zapcore.Level
+	New     zapcore.Level
+	// Target is the package, prefix or glob the change was made on,
+	// empty when the default level was changed
+	Target string
+	// Source identifies what made the change, such as "api" for calls to
+	// SetLevelForPackage, "admin" for the AdminHandler or "configure" for Configure
+	Source string
+}
+
+// OnLevelChange registers fn to be called for every change to the effective
+// log level of a package, which can be used to audit level changes. fn is
+// called synchronously before the change returns, with events in the order
+// changes were applied, so the Old level of an event is the New level of the
+// previous one for the same package. A change that leaves every effective level
+// as it was, such as setting a package to the level it inherits or setting the
+// default level before any package is registered, produces no events. fn must
+// not change levels itself. The returned func stops further calls to fn
+func OnLevelChange(fn func(LevelEvent)) (cancel func()) {
+	return registry.Watch(func(e registry.Event) {
+		fn(LevelEvent{
+			Package: string(e.Package),
+			Old:     e.Old,
+			New:     e.New,
+			Target:  string(e.Target),
+			Source:  string(e.Source),
+		})
+	})
+}
@@ -41,8 +41,10 @@ func Test_consoleConfig(t *testing.T) {
 				global.mode = mode.Production
 			},
 			want: console.Config{
-				Mode:   mode.Production,
-				Config: encode.ProConsoleConfig,
+				Mode:     mode.Production,
+				Config:   encode.ProConsoleConfig,
+				HideRoot: true,
+				Colors:   console.NeverColors,
 			},
 		},
 		{
@@ -51,16 +53,31 @@ func Test_consoleConfig(t *testing.T) {
 				global.mode = mode.Development
 			},
 			want: console.Config{
-				Mode:   mode.Development,
-				Config: encode.DevConsoleConfig,
+				Mode:     mode.Development,
+				Config:   encode.ProConsoleConfig,
+				HideRoot: true,
+				Colors:   console.NeverColors,
+			},
+		},
+		{
+			name: "colors",
+			setup: func() {
+				global.mode = mode.Production
+				global.ccolors = encode.ColorAlways
+			},
+			want: console.Config{
+				Mode:     mode.Production,
+				Config:   encode.DevConsoleConfig,
+				HideRoot: true,
+				Colors:   console.AlwaysColors,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
-			if got := consoleConfig(); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("consoleConfig() = %v, want %v", got, tt.want)
+			if got := consoleConfig(nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("consoleConfig(nil) = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -144,6 +161,7 @@ func TestNew(t *testing.T) {
 				Configure(
 					AppName("test-app"),
 					ConsoleWriter(consolew),
+					ConsoleColors(encode.ColorAlways),
 				)
 			},
 			checkinfo: func(t *testing.T) {
@@ -156,11 +174,11 @@ func TestNew(t *testing.T) {
 
 				assert.Equal(t, "\x1b[36mINFO\x1b[0m", output[0])
 				assert.True(t, correctFormat(output[1]))
-				assert.Equal(t, "\x1b[36mcaller\x1b[0m=logger/logger_test.go:272", output[2])
+				assert.Equal(t, "\x1b[36mcaller\x1b[0m=logger/logger_test.go:291", output[2])
 				assert.Equal(t, "\x1b[36mmessage\x1b[0m=hello", output[3])
-				assert.Equal(t, "\x1b[36mstatus\x1b[0m=blue", output[10])
-				assert.Equal(t, "\x1b[36mcount\x1b[0m=12", output[11])
-				assert.Equal(t, "\x1b[36mapplication\x1b[0m=test-app\n", output[13])
+				assert.Equal(t, "\x1b[36mstatus\x1b[0m=blue", output[12])
+				assert.Equal(t, "\x1b[36mcount\x1b[0m=12\n", output[13])
+				assert.Equal(t, "\x1b[36mapplication\x1b[0m=test-app", output[11])
 			},
 			checkwarn: func(t *testing.T) {
 				output := strings.Split(consolew.log, " ")
@@ -171,11 +189,11 @@ func TestNew(t *testing.T) {
 
 				assert.Equal(t, "\x1b[33mWARN\x1b[0m", output[0])
 				assert.True(t, correctFormat(output[1]))
-				assert.Equal(t, "\x1b[33mcaller\x1b[0m=logger/logger_test.go:278", output[2])
+				assert.Equal(t, "\x1b[33mcaller\x1b[0m=logger/logger_test.go:297", output[2])
 				assert.Equal(t, "\x1b[33mmessage\x1b[0m=hello", output[3])
-				assert.Equal(t, "\x1b[33mstatus\x1b[0m=yellow", output[10])
-				assert.Equal(t, "\x1b[33mcount\x1b[0m=54", output[11])
-				assert.Equal(t, "\x1b[33mapplication\x1b[0m=test-app\n", output[13])
+				assert.Equal(t, "\x1b[33mstatus\x1b[0m=yellow", output[12])
+				assert.Equal(t, "\x1b[33mcount\x1b[0m=54\n", output[13])
+				assert.Equal(t, "\x1b[33mapplication\x1b[0m=test-app", output[11])
 			},
 			checkerror: func(t *testing.T) {
 				output := strings.Split(consolew.log, " ")
@@ -186,11 +204,11 @@ func TestNew(t *testing.T) {
 
 				assert.Equal(t, "\x1b[31mERROR\x1b[0m", output[0])
 				assert.True(t, correctFormat(output[1]))
-				assert.Equal(t, "\x1b[31mcaller\x1b[0m=logger/logger_test.go:284", output[2])
+				assert.Equal(t, "\x1b[31mcaller\x1b[0m=logger/logger_test.go:303", output[2])
 				assert.Equal(t, "\x1b[31mmessage\x1b[0m=hello", output[3])
-				assert.Equal(t, "\x1b[31mstatus\x1b[0m=red", output[10])
-				assert.Equal(t, "\x1b[31mcount\x1b[0m=9102", output[11])
-				assert.Equal(t, "\x1b[31mapplication\x1b[0m=test-app\n", output[13])
+				assert.Equal(t, "\x1b[31mstatus\x1b[0m=red", output[12])
+				assert.Equal(t, "\x1b[31mcount\x1b[0m=9102\n", output[13])
+				assert.Equal(t, "\x1b[31mapplication\x1b[0m=test-app", output[11])
 			},
 		},
 		{
@@ -201,6 +219,7 @@ func TestNew(t *testing.T) {
 					ConsoleWriter(consolew),
 					JSONWriter(jsonw),
 					Mode(mode.Production),
+					ConsoleColors(encode.ColorAuto),
 				)
 			},
 			checkinfo: func(t *testing.T) {
@@ -217,11 +236,11 @@ func TestNew(t *testing.T) {
 
 				assert.Equal(t, "INFO", output[0])
 				assert.True(t, correctFormat(output[1]))
-				assert.Equal(t, "caller=logger/logger_test.go:272", output[2])
+				assert.Equal(t, "caller=logger/logger_test.go:291", output[2])
 				assert.Equal(t, "message=hello", output[3])
-				assert.Equal(t, "status=blue", output[10])
-				assert.Equal(t, "count=12", output[11])
-				assert.Equal(t, "application=test-app\n", output[13])
+				assert.Equal(t, "status=blue", output[12])
+				assert.Equal(t, "count=12\n", output[13])
+				assert.Equal(t, "application=test-app", output[11])
 			},
 			checkwarn: func(t *testing.T) {
 				output := strings.Split(consolew.log, " ")
@@ -237,11 +256,11 @@ func TestNew(t *testing.T) {
 
 				assert.Equal(t, "WARN", output[0])
 				assert.True(t, correctFormat(output[1]))
-				assert.Equal(t, "caller=logger/logger_test.go:278", output[2])
+				assert.Equal(t, "caller=logger/logger_test.go:297", output[2])
 				assert.Equal(t, "message=hello", output[3])
-				assert.Equal(t, "status=yellow", output[10])
-				assert.Equal(t, "count=54", output[11])
-				assert.Equal(t, "application=test-app\n", output[13])
+				assert.Equal(t, "status=yellow", output[12])
+				assert.Equal(t, "count=54\n", output[13])
+				assert.Equal(t, "application=test-app", output[11])
 			},
 			checkerror: func(t *testing.T) {
 				output := strings.Split(consolew.log, " ")
@@ -257,11 +276,11 @@ func TestNew(t *testing.T) {
 
 				assert.Equal(t, "ERROR", output[0])
 				assert.True(t, correctFormat(output[1]))
-				assert.Equal(t, "caller=logger/logger_test.go:284", output[2])
+				assert.Equal(t, "caller=logger/logger_test.go:303", output[2])
 				assert.Equal(t, "message=hello", output[3])
-				assert.Equal(t, "status=red", output[10])
-				assert.Equal(t, "count=9102", output[11])
-				assert.Equal(t, "application=test-app\n", output[13])
+				assert.Equal(t, "status=red", output[12])
+				assert.Equal(t, "count=9102\n", output[13])
+				assert.Equal(t, "application=test-app", output[11])
 			},
 		},
 	}
@@ -289,3 +308,46 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPackageLevels(t *testing.T) {
+	before()
+	defer after()
+	defer registry.Reset()
+	registry.Reset()
+
+	registry.Get("acme/svc")
+	registry.Get("acme/svc/store")
+
+	assert.NoError(t, SetLevelForPackage("acme", zap.DebugLevel))
+	assert.NoError(t, SetLevelForPackage("acme/svc/store", zap.ErrorLevel))
+	assert.Equal(t, []PackageLevel{
+		{Package: "acme/svc", Level: zap.DebugLevel, From: "acme"},
+		{Package: "acme/svc/store", Level: zap.ErrorLevel, Explicit: true, From: "acme/svc/store"},
+	}, GetPackageLevels())
+
+	assert.NoError(t, ClearLevelForPackage("acme/svc/store"))
+	assert.Equal(t, []PackageLevel{
+		{Package: "acme/svc", Level: zap.DebugLevel, From: "acme"},
+		{Package: "acme/svc/store", Level: zap.DebugLevel, From: "acme"},
+	}, GetPackageLevels())
+}
+
+func TestOnLevelChange(t *testing.T) {
+	before()
+	defer after()
+	defer registry.Reset()
+	registry.Reset()
+
+	registry.Get("acme/svc")
+
+	var events []LevelEvent
+	cancel := OnLevelChange(func(e LevelEvent) {
+		events = append(events, e)
+	})
+	defer cancel()
+
+	assert.NoError(t, SetLevelForPackage("acme", zap.DebugLevel))
+	assert.Equal(t, []LevelEvent{
+		{Package: "acme/svc", Old: zap.InfoLevel, New: zap.DebugLevel, Target: "acme", Source: "api"},
+	}, events)
+}
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// RequestIDHeader is the default header a request ID is read from and written to
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLength is the length beyond which incoming request IDs are replaced
+	maxRequestIDLength = 128
+)
+
+// A MiddlewareOption configures the handler returned by Middleware
+type MiddlewareOption func(m *middleware)
+
+// RequestIDFrom sets the header the request ID is read from and written to
+func RequestIDFrom(header string) MiddlewareOption {
+	return func(m *middleware) {
+		m.header = header
+	}
+}
+
+// GenerateRequestID sets the func used to create a request ID when the request
+// does not carry one. By default IDs are 16 random bytes, hex encoded
+func GenerateRequestID(fn func() string) MiddlewareOption {
+	return func(m *middleware) {
+		m.generate = fn
+	}
+}
+
+// StatusLevel sets the func that chooses the level of the access entry of a
+// request from its status code. By default server errors are logged at error
+// level, client errors at warn level and everything else at info level
+func StatusLevel(fn func(status int) zapcore.Level) MiddlewareOption {
+	return func(m *middleware) {
+		m.level = fn
+	}
+}
+
+// Middleware returns an http.Handler that wraps next with request logging. The
+// request ID of every request is taken from its X-Request-ID header, or generated
+// when there is none, and echoed in the response. It is added to the request
+// context with WithContext, along with the trace of its traceparent header,
+// so FromContext returns loggers that include them.
+// Once next returns, a single access entry with the method, path, status,
+// bytes written, duration and remote address of the request is logged by the
+// logger of the package Middleware was called in
+func Middleware(next http.Handler, options ...MiddlewareOption) http.Handler {
+	m := &middleware{
+		next:     next,
+		log:      newLogger(pkgname()).WithOptions(zap.WithCaller(false)),
+		header:   RequestIDHeader,
+		generate: newRequestID,
+		level:    levelForStatus,
+	}
+
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+type middleware struct {
+	next     http.Handler
+	log      *zap.Logger
+	header   string
+	generate func() string
+	level    func(status int) zapcore.Level
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	id := r.Header.Get(m.header)
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		id = m.generate()
+	}
+	w.Header().Set(m.header, id)
+
+	ctx := WithContext(r.Context(), zap.String("request_id", id))
+	if traceparent := r.Header.Get(TraceparentHeader); len(traceparent) > 0 {
+		ctx = WithTraceparent(ctx, traceparent)
+	}
+
+	rec := &responseRecorder{ResponseWriter: w}
+	m.next.ServeHTTP(rec, r.WithContext(ctx))
+
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	log := m.log
+	if trace := traceFields(ctx); len(trace) > 0 {
+		log = log.With(trace...)
+	}
+
+	if ce := log.Check(m.level(status), "http request"); ce != nil {
+		ce.Write(
+			zap.String("request_id", id),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", status),
+			zap.Int64("bytes", rec.bytes),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("remote_addr", r.RemoteAddr),
+		)
+	}
+}
+
+// levelForStatus is the default level of access entries
+func levelForStatus(status int) zapcore.Level {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// newRequestID returns 16 random bytes, hex encoded
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// responseRecorder records the status and number of bytes of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher when the wrapped writer does
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// errNotHijacker is returned by Hijack when the wrapped writer does not support it
+var errNotHijacker = errors.New("logger: the response writer does not implement http.Hijacker")
+
+// Hijack implements http.Hijacker when the wrapped writer does, so that
+// connections can be upgraded, for example to websockets
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errNotHijacker
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
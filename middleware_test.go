@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// accessEntry is the part of a json access entry checked by the tests
+type accessEntry struct {
+	Level   string `json:"level"`
+	Message string `json:"@message"`
+	Fields  struct {
+		RequestID  string  `json:"request_id"`
+		Method     string  `json:"method"`
+		Path       string  `json:"path"`
+		Status     int     `json:"status"`
+		Bytes      int64   `json:"bytes"`
+		Duration   float64 `json:"duration"`
+		RemoteAddr string  `json:"remote_addr"`
+		Caller     string  `json:"caller"`
+	} `json:"@fields"`
+}
+
+func TestMiddleware(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	tests := []struct {
+		name      string
+		requestID string
+		handler   http.HandlerFunc
+		wantLevel string
+		wantCode  int
+		wantBytes int64
+	}{
+		{
+			name: "ok",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "hello")
+			},
+			wantLevel: "INFO",
+			wantCode:  http.StatusOK,
+			wantBytes: 5,
+		},
+		{
+			name:      "propagated request id",
+			requestID: "abc-123",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			wantLevel: "INFO",
+			wantCode:  http.StatusNoContent,
+		},
+		{
+			name: "client error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			wantLevel: "WARN",
+			wantCode:  http.StatusNotFound,
+			wantBytes: 19,
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				w.WriteHeader(http.StatusOK)
+			},
+			wantLevel: "ERROR",
+			wantCode:  http.StatusBadGateway,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			Configure(ConsoleWriter(nil), JSONWriter(out), Mode(mode.Production))
+
+			req := httptest.NewRequest(http.MethodGet, "/users/42?verbose=true", nil)
+			req.RemoteAddr = "10.0.0.1:5000"
+			if len(tt.requestID) > 0 {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			Middleware(tt.handler).ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if len(tt.requestID) > 0 {
+				assert.Equal(t, tt.requestID, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+
+			var entry accessEntry
+			assert.NoError(t, json.Unmarshal(out.Bytes(), &entry), out.String())
+			assert.Equal(t, tt.wantLevel, entry.Level)
+			assert.Equal(t, "http request", entry.Message)
+			assert.Equal(t, id, entry.Fields.RequestID)
+			assert.Equal(t, http.MethodGet, entry.Fields.Method)
+			assert.Equal(t, "/users/42", entry.Fields.Path)
+			assert.Equal(t, tt.wantCode, entry.Fields.Status)
+			assert.Equal(t, tt.wantBytes, entry.Fields.Bytes)
+			assert.True(t, entry.Fields.Duration > 0)
+			assert.Equal(t, "10.0.0.1:5000", entry.Fields.RemoteAddr)
+			assert.Empty(t, entry.Fields.Caller)
+		})
+	}
+}
+
+func TestMiddleware_context(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	out := new(bytes.Buffer)
+	Configure(ConsoleWriter(out), Mode(mode.Production))
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("handling")
+	}),
+		RequestIDFrom("X-Correlation-ID"),
+		GenerateRequestID(func() string { return "generated" }),
+		StatusLevel(func(int) zapcore.Level { return zap.DebugLevel }),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "generated", rec.Header().Get("X-Correlation-ID"))
+	assert.Contains(t, out.String(), "message=handling @source_host=")
+	assert.Contains(t, out.String(), "request_id=generated")
+	assert.NotContains(t, out.String(), "http request", "debug access entries are disabled")
+
+	// overly long request ids are replaced
+	out.Reset()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", strings.Repeat("x", 200))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "generated", rec.Header().Get("X-Correlation-ID"))
+}
+
+func TestResponseRecorder_Flush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &responseRecorder{ResponseWriter: rec}
+	w.Flush()
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, rec, w.Unwrap())
+}
+
+func TestResponseRecorder_Hijack(t *testing.T) {
+	t.Run("not supported", func(t *testing.T) {
+		w := &responseRecorder{ResponseWriter: httptest.NewRecorder()}
+		_, _, err := w.Hijack()
+		assert.Equal(t, errNotHijacker, err)
+	})
+
+	t.Run("upgrade", func(t *testing.T) {
+		recorded := make(chan int, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &responseRecorder{ResponseWriter: w}
+			conn, rw, err := http.ResponseWriter(rec).(http.Hijacker).Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+			rw.Flush()
+			recorded <- rec.status
+		}))
+		defer server.Close()
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		resp, err := http.DefaultClient.Do(req)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		}
+		assert.Equal(t, http.StatusSwitchingProtocols, <-recorded)
+	})
+}
@@ -1,5 +1,10 @@
 package mode
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Kind represents the mode an logger should run in -
 // for example: Dev or Pro
 type Kind int8
@@ -10,3 +15,33 @@ const (
 	Development
 	Production
 )
+
+// String returns the lower case name of the mode
+func (k Kind) String() string {
+	switch k {
+	case None:
+		return "none"
+	case Development:
+		return "development"
+	case Production:
+		return "production"
+	default:
+		return fmt.Sprintf("Kind(%d)", k)
+	}
+}
+
+// Parse returns the mode Kind for the provided name. Names are
+// case insensitive, and the short forms "dev" and "pro" or "prod"
+// are accepted as well
+func Parse(name string) (Kind, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "none":
+		return None, nil
+	case "development", "dev":
+		return Development, nil
+	case "production", "pro", "prod":
+		return Production, nil
+	default:
+		return 0, fmt.Errorf("unrecognized mode: %q", name)
+	}
+}
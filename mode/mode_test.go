@@ -0,0 +1,42 @@
+package mode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    Kind
+		wantErr bool
+	}{
+		{name: "none", arg: "none", want: None},
+		{name: "development", arg: "development", want: Development},
+		{name: "dev short", arg: "dev", want: Development},
+		{name: "production", arg: "Production", want: Production},
+		{name: "pro short", arg: " pro ", want: Production},
+		{name: "prod short", arg: "PROD", want: Production},
+		{name: "unknown", arg: "staging", wantErr: true},
+		{name: "empty", arg: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKind_String(t *testing.T) {
+	assert.Equal(t, "none", None.String())
+	assert.Equal(t, "development", Development.String())
+	assert.Equal(t, "production", Production.String())
+	assert.Equal(t, "Kind(9)", Kind(9).String())
+}
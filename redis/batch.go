@@ -0,0 +1,203 @@
+package redis
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned when an entry is dropped because the
+// queue of a batching Sink is full
+var ErrQueueFull = errors.New("the redis sink queue is full")
+
+// OverflowPolicy determines what a batching Sink does with
+// new entries when its queue is full
+type OverflowPolicy int8
+
+// Possible overflow policies of a batching Sink
+const (
+	// Block waits for room in the queue, slowing down the caller
+	Block OverflowPolicy = iota + 1
+	// Drop discards the entry and returns ErrQueueFull
+	Drop
+)
+
+// Batch enables batching: entries are queued in memory and delivered with a
+// single command once size entries are pending, as well as every interval.
+// An interval of zero only delivers on size, Sync and Close
+func Batch(size int, interval time.Duration) Option {
+	return func(s *Sink) {
+		if size < 1 {
+			size = 1
+		}
+		s.batchSize = size
+		s.batchInterval = interval
+	}
+}
+
+// QueueSize bounds the number of entries a batching Sink holds in memory,
+// which defaults to ten times the batch size. It has no effect without Batch
+func QueueSize(n int) Option {
+	return func(s *Sink) {
+		if n > 0 {
+			s.queueSize = n
+		}
+	}
+}
+
+// OnOverflow sets what a batching Sink does when its queue is full,
+// which defaults to Block. It has no effect without Batch
+func OnOverflow(policy OverflowPolicy) Option {
+	return func(s *Sink) {
+		s.overflow = policy
+	}
+}
+
+// newBatcher returns the batcher configured by the batch options of s,
+// or nil when batching is not enabled
+func (s *Sink) newBatcher() *batcher {
+	if s.batchSize == 0 {
+		return nil
+	}
+
+	queueSize := s.queueSize
+	if queueSize == 0 {
+		queueSize = 10 * s.batchSize
+	}
+	overflow := s.overflow
+	if overflow == 0 {
+		overflow = Block
+	}
+
+	return &batcher{
+		size:     s.batchSize,
+		interval: s.batchInterval,
+		overflow: overflow,
+		queue:    make(chan []byte, queueSize),
+		flushes:  make(chan chan error),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		flush:    s.deliver,
+	}
+}
+
+type batcher struct {
+	size     int
+	interval time.Duration
+	overflow OverflowPolicy
+
+	queue   chan []byte
+	flushes chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	// flush delivers a batch of entries
+	flush func([][]byte) error
+	// err is the first delivery error since the last sync,
+	// only accessed by the run loop
+	err error
+}
+
+// add queues a copy of p, as zap reuses the buffers it writes
+func (b *batcher) add(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	select {
+	case <-b.done:
+		return 0, ErrClosed
+	default:
+	}
+
+	if b.overflow == Drop {
+		select {
+		case b.queue <- entry:
+			return len(p), nil
+		default:
+			return 0, ErrQueueFull
+		}
+	}
+
+	select {
+	case b.queue <- entry:
+		return len(p), nil
+	case <-b.done:
+		return 0, ErrClosed
+	}
+}
+
+func (b *batcher) sync() error {
+	reply := make(chan error, 1)
+	select {
+	case b.flushes <- reply:
+		return <-reply
+	case <-b.stopped:
+		return ErrClosed
+	}
+}
+
+func (b *batcher) close() {
+	b.once.Do(func() {
+		close(b.done)
+	})
+	<-b.stopped
+}
+
+func (b *batcher) run() {
+	defer close(b.stopped)
+
+	var tick <-chan time.Time
+	if b.interval > 0 {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	pending := make([][]byte, 0, b.size)
+	deliver := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := b.flush(pending); err != nil && b.err == nil {
+			b.err = err
+		}
+		pending = make([][]byte, 0, b.size)
+	}
+
+	enqueue := func(entry []byte) {
+		pending = append(pending, entry)
+		if len(pending) >= b.size {
+			deliver()
+		}
+	}
+
+	// drain delivers everything that is currently queued
+	drain := func() {
+		for {
+			select {
+			case entry := <-b.queue:
+				enqueue(entry)
+			default:
+				deliver()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case entry := <-b.queue:
+			enqueue(entry)
+		case <-tick:
+			deliver()
+		case reply := <-b.flushes:
+			drain()
+			reply <- b.err
+			b.err = nil
+		case <-b.done:
+			drain()
+			return
+		}
+	}
+}
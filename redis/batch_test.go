@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/syllabix/logger/redis/internal/mocks"
+)
+
+const batchKey = "logstash.stg.bolcom"
+
+func newBatchPool(conn *mocks.Conn) *mocks.Pool {
+	conn.On("Close").Return(nil)
+	pool := new(mocks.Pool)
+	pool.On("Get").Return(conn)
+	pool.On("Close").Return(nil)
+	return pool
+}
+
+func waitFor(t *testing.T, signal <-chan struct{}) {
+	select {
+	case <-signal:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for redis command")
+	}
+}
+
+func TestBatch_flushes(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		writes  []string
+		want    []interface{}
+	}{
+		{
+			name:    "on size",
+			options: []Option{Batch(3, 0)},
+			writes:  []string{"one", "two", "three"},
+			want:    []interface{}{batchKey, "one", "two", "three"},
+		},
+		{
+			name:    "on interval",
+			options: []Option{Batch(100, 10*time.Millisecond)},
+			writes:  []string{"one", "two"},
+			want:    []interface{}{batchKey, "one", "two"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivered := make(chan struct{}, 1)
+			conn := new(mocks.Conn)
+			conn.On("Do", "RPUSH", tt.want).Return(int64(len(tt.writes)), nil).Run(func(mock.Arguments) {
+				delivered <- struct{}{}
+			})
+
+			s := NewSink(batchKey, newBatchPool(conn), tt.options...)
+			defer s.Close()
+
+			for _, w := range tt.writes {
+				n, err := s.Write([]byte(w))
+				assert.NoError(t, err)
+				assert.Equal(t, len(w), n)
+			}
+
+			waitFor(t, delivered)
+			conn.AssertNumberOfCalls(t, "Do", 1)
+		})
+	}
+}
+
+func TestBatch_Sync(t *testing.T) {
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "one", "two"}).Return(int64(2), nil).Once()
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "three"}).Return(nil, errors.New("connection refused")).Once()
+
+	s := NewSink(batchKey, newBatchPool(conn), Batch(100, 0))
+	defer s.Close()
+
+	buf := []byte("one")
+	s.Write(buf)
+	// zap reuses buffers, so entries must be copied when queued
+	copy(buf, "two")
+	s.Write(buf)
+	assert.NoError(t, s.Sync())
+
+	s.Write([]byte("three"))
+	assert.EqualError(t, s.Sync(), "connection refused")
+
+	// errors are only reported once
+	assert.NoError(t, s.Sync())
+	conn.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestBatch_overflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		asserter func(t *testing.T, s *Sink, release chan struct{})
+	}{
+		{
+			name:    "drop",
+			options: []Option{Batch(1, 0), QueueSize(1), OnOverflow(Drop)},
+			asserter: func(t *testing.T, s *Sink, release chan struct{}) {
+				n, err := s.Write([]byte("three"))
+				assert.Equal(t, 0, n)
+				assert.Equal(t, ErrQueueFull, err)
+				close(release)
+			},
+		},
+		{
+			name:    "drop before batch",
+			options: []Option{OnOverflow(Drop), QueueSize(1), Batch(1, 0)},
+			asserter: func(t *testing.T, s *Sink, release chan struct{}) {
+				n, err := s.Write([]byte("three"))
+				assert.Equal(t, 0, n)
+				assert.Equal(t, ErrQueueFull, err)
+				close(release)
+			},
+		},
+		{
+			name:    "block",
+			options: []Option{Batch(1, 0), QueueSize(1), OnOverflow(Block)},
+			asserter: func(t *testing.T, s *Sink, release chan struct{}) {
+				written := make(chan struct{})
+				go func() {
+					s.Write([]byte("three"))
+					close(written)
+				}()
+
+				select {
+				case <-written:
+					t.Fatal("write should block while the queue is full")
+				case <-time.After(20 * time.Millisecond):
+				}
+
+				close(release)
+				waitFor(t, written)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{}, 1)
+			release := make(chan struct{})
+
+			conn := new(mocks.Conn)
+			conn.On("Do", "RPUSH", mock.Anything).Return(int64(1), nil).Run(func(mock.Arguments) {
+				select {
+				case started <- struct{}{}:
+				default:
+				}
+				<-release
+			})
+
+			s := NewSink(batchKey, newBatchPool(conn), tt.options...)
+			defer s.Close()
+
+			// the first entry is being delivered, the second fills the queue
+			s.Write([]byte("one"))
+			waitFor(t, started)
+			s.Write([]byte("two"))
+
+			tt.asserter(t, s, release)
+		})
+	}
+}
+
+func TestBatch_Close(t *testing.T) {
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "one", "two"}).Return(int64(2), nil)
+	pool := newBatchPool(conn)
+
+	s := NewSink(batchKey, pool, Batch(100, time.Hour))
+	s.Write([]byte("one"))
+	s.Write([]byte("two"))
+	assert.NoError(t, s.Close())
+
+	conn.AssertCalled(t, "Do", "RPUSH", []interface{}{batchKey, "one", "two"})
+	pool.AssertNumberOfCalls(t, "Close", 1)
+
+	n, err := s.Write([]byte("three"))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, ErrClosed, s.Sync())
+}
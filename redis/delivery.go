@@ -0,0 +1,164 @@
+package redis
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// command is a single redis command
+type command struct {
+	name string
+	args []interface{}
+}
+
+// delivery builds the commands that deliver entries to a key
+type delivery interface {
+	commands(key string, entries [][]byte) []command
+}
+
+// List delivers entries with RPUSH to the list at the sink key, which is the default
+func List() Option {
+	return func(s *Sink) {
+		s.delivery = list{}
+	}
+}
+
+// Stream delivers entries with XADD to the stream at the sink key. When maxLen is
+// greater than zero the stream is trimmed to approximately maxLen entries. Without
+// any StreamField mappings, each entry is added as a single "entry" field
+func Stream(maxLen int64) Option {
+	return func(s *Sink) {
+		s.delivery = &stream{maxLen: maxLen}
+	}
+}
+
+// StreamField adds a field to the stream entries written by a Stream sink, holding
+// the value of key in the JSON encoded log entry. Nested keys are separated by dots,
+// for example "@fields.application", and an empty key holds the whole log entry.
+// Fields whose key is not present in a log entry are left out
+func StreamField(field, key string) Option {
+	return func(s *Sink) {
+		s.streamFields = append(s.streamFields, fieldMapping{field: field, key: key})
+	}
+}
+
+// Publish delivers entries with PUBLISH to the channel named by the sink key
+func Publish() Option {
+	return func(s *Sink) {
+		s.delivery = publish{}
+	}
+}
+
+type list struct{}
+
+func (list) commands(key string, entries [][]byte) []command {
+	args := make([]interface{}, 0, len(entries)+1)
+	args = append(args, key)
+	for _, entry := range entries {
+		args = append(args, string(entry))
+	}
+	return []command{{name: "RPUSH", args: args}}
+}
+
+type publish struct{}
+
+func (publish) commands(key string, entries [][]byte) []command {
+	cmds := make([]command, len(entries))
+	for i, entry := range entries {
+		cmds[i] = command{name: "PUBLISH", args: []interface{}{key, string(entry)}}
+	}
+	return cmds
+}
+
+type fieldMapping struct {
+	field string
+	key   string
+}
+
+// entryField is the stream field holding the whole entry when no fields are mapped
+const entryField = "entry"
+
+type stream struct {
+	maxLen int64
+	fields []fieldMapping
+}
+
+func (st *stream) commands(key string, entries [][]byte) []command {
+	cmds := make([]command, len(entries))
+	for i, entry := range entries {
+		args := []interface{}{key}
+		if st.maxLen > 0 {
+			args = append(args, "MAXLEN", "~", st.maxLen)
+		}
+		args = append(args, "*")
+		cmds[i] = command{name: "XADD", args: append(args, st.values(entry)...)}
+	}
+	return cmds
+}
+
+// values returns the field value pairs of a stream entry
+func (st *stream) values(entry []byte) []interface{} {
+	if len(st.fields) == 0 {
+		return []interface{}{entryField, string(entry)}
+	}
+
+	decoded := decode(entry)
+
+	values := make([]interface{}, 0, 2*len(st.fields))
+	for _, m := range st.fields {
+		if len(m.key) == 0 {
+			values = append(values, m.field, string(entry))
+			continue
+		}
+		if val, ok := lookup(decoded, m.key); ok {
+			values = append(values, m.field, val)
+		}
+	}
+
+	// a stream entry needs at least one field
+	if len(values) == 0 {
+		return []interface{}{entryField, string(entry)}
+	}
+	return values
+}
+
+// decode returns the JSON object in entry, or nil if it is not one
+func decode(entry []byte) map[string]interface{} {
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(entry, &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// lookup finds key in a decoded JSON object, following dots into nested
+// objects when the key itself is not present, and returns its value as a
+// string. Values that are not strings are returned JSON encoded
+func lookup(obj map[string]interface{}, key string) (string, bool) {
+	if obj == nil {
+		return "", false
+	}
+
+	val, ok := obj[key]
+	if !ok {
+		i := strings.IndexByte(key, '.')
+		if i < 0 {
+			return "", false
+		}
+		nested, isObj := obj[key[:i]].(map[string]interface{})
+		if !isObj {
+			return "", false
+		}
+		return lookup(nested, key[i+1:])
+	}
+
+	if str, isStr := val.(string); isStr {
+		return str, true
+	}
+
+	encoded, err := json.Marshal(val)
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
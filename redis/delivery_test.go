@@ -0,0 +1,158 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/redis/internal/mocks"
+)
+
+const entry = `{"@message":"hello","@fields":{"level":"info","application":"app","attempt":2}}`
+
+func TestStream_values(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		entry   string
+		want    []interface{}
+	}{
+		{
+			name:  "whole entry by default",
+			entry: entry,
+			want:  []interface{}{"entry", entry},
+		},
+		{
+			name: "mapped fields",
+			options: []Option{
+				StreamField("msg", "@message"),
+				StreamField("level", "@fields.level"),
+				StreamField("app", "@fields.application"),
+				StreamField("attempt", "@fields.attempt"),
+				StreamField("raw", ""),
+			},
+			entry: entry,
+			want: []interface{}{
+				"msg", "hello",
+				"level", "info",
+				"app", "app",
+				"attempt", "2",
+				"raw", entry,
+			},
+		},
+		{
+			name:    "nested objects are json encoded",
+			options: []Option{StreamField("fields", "@fields")},
+			entry:   `{"@fields":{"a":1}}`,
+			want:    []interface{}{"fields", `{"a":1}`},
+		},
+		{
+			name: "missing keys are left out",
+			options: []Option{
+				StreamField("msg", "@message"),
+				StreamField("host", "@source_host"),
+				StreamField("user", "@fields.user.id"),
+			},
+			entry: entry,
+			want:  []interface{}{"msg", "hello"},
+		},
+		{
+			name:    "dotted keys match before nesting",
+			options: []Option{StreamField("trace", "trace.id")},
+			entry:   `{"trace.id":"abc","trace":{"id":"def"}}`,
+			want:    []interface{}{"trace", "abc"},
+		},
+		{
+			name:    "falls back to the whole entry",
+			options: []Option{StreamField("msg", "@message")},
+			entry:   "INFO hello",
+			want:    []interface{}{"entry", "INFO hello"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSink(batchKey, nil, append([]Option{Stream(0)}, tt.options...)...)
+			st := s.delivery.(*stream)
+			assert.Equal(t, tt.want, st.values([]byte(tt.entry)))
+		})
+	}
+}
+
+func TestStreamField_withoutStream(t *testing.T) {
+	s := NewSink(batchKey, nil, StreamField("msg", "@message"), Publish())
+	assert.Equal(t, publish{}, s.delivery)
+}
+
+func TestSink_Stream(t *testing.T) {
+	conn := new(mocks.Conn)
+	conn.On("Do", "XADD", []interface{}{batchKey, "MAXLEN", "~", int64(1000), "*", "msg", "hello"}).Return("1-0", nil)
+
+	s := NewSink(batchKey, newBatchPool(conn), Stream(1000), StreamField("msg", "@message"))
+	n, err := s.Write([]byte(`{"@message":"hello"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 20, n)
+	conn.AssertExpectations(t)
+}
+
+func TestStreamField_beforeStream(t *testing.T) {
+	s := NewSink(batchKey, nil, StreamField("msg", "@message"), Stream(0), StreamField("level", "@fields.level"))
+	st := s.delivery.(*stream)
+	assert.Equal(t, []interface{}{"msg", "hello", "level", "info"}, st.values([]byte(entry)))
+}
+
+func TestSink_Publish(t *testing.T) {
+	conn := new(mocks.Conn)
+	conn.On("Do", "PUBLISH", []interface{}{batchKey, "hello"}).Return(int64(2), nil)
+
+	s := NewSink(batchKey, newBatchPool(conn), Publish())
+	_, err := s.Write([]byte("hello"))
+	assert.NoError(t, err)
+	conn.AssertExpectations(t)
+}
+
+func TestSink_pipelines(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		want    []command
+	}{
+		{
+			name:    "stream",
+			options: []Option{Stream(0)},
+			want: []command{
+				{name: "XADD", args: []interface{}{batchKey, "*", "entry", "one"}},
+				{name: "XADD", args: []interface{}{batchKey, "*", "entry", "two"}},
+			},
+		},
+		{
+			name:    "publish",
+			options: []Option{Publish()},
+			want: []command{
+				{name: "PUBLISH", args: []interface{}{batchKey, "one"}},
+				{name: "PUBLISH", args: []interface{}{batchKey, "two"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := new(mocks.Conn)
+			for _, cmd := range tt.want {
+				conn.On("Send", cmd.name, cmd.args).Return(nil).Once()
+			}
+			conn.On("Do", "", []interface{}(nil)).Return([]interface{}{int64(1), int64(1)}, nil)
+
+			s := NewSink(batchKey, newBatchPool(conn), tt.options...)
+			assert.NoError(t, s.push([][]byte{[]byte("one"), []byte("two")}))
+			conn.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSink_pipelineError(t *testing.T) {
+	conn := new(mocks.Conn)
+	conn.On("Send", "PUBLISH", []interface{}{batchKey, "one"}).Return(errRefused)
+
+	s := NewSink(batchKey, newBatchPool(conn), Publish())
+	assert.Equal(t, errRefused, s.push([][]byte{[]byte("one"), []byte("two")}))
+	conn.AssertNumberOfCalls(t, "Send", 1)
+	conn.AssertNotCalled(t, "Do", "", []interface{}(nil))
+}
@@ -0,0 +1,166 @@
+package redis
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// unresolved replaces placeholders of a key template that have no value in an entry
+const unresolved = "unknown"
+
+// KeyTemplate resolves the key of every entry from tmpl, so that entries of different
+// applications or days land in different lists, streams or channels. Placeholders in
+// braces are replaced with the value of that key in the JSON encoded entry, looked up
+// at the top level first and in "@fields" next, as in "logs:{application}:{date}".
+// {date} is the date of the entry's @timestamp, or the current UTC date when it has
+// none, formatted as 2006-01-02. Placeholders without a value resolve to "unknown".
+// The key passed to NewSink is used for entries that are not JSON encoded
+func KeyTemplate(tmpl string) Option {
+	return func(s *Sink) {
+		s.template = parseTemplate(tmpl)
+	}
+}
+
+// Trim caps the list of a sink to its most recent maxLen entries, issuing an LTRIM
+// after every push. It has no effect on stream or publish delivery
+func Trim(maxLen int64) Option {
+	return func(s *Sink) {
+		s.trim = maxLen
+	}
+}
+
+// TTL sets the time to live of the keys of a sink, issuing an EXPIRE after every
+// push, so that lists and streams nobody consumes are eventually removed. It has
+// no effect on publish delivery
+func TTL(d time.Duration) Option {
+	return func(s *Sink) {
+		s.ttl = d
+	}
+}
+
+// segment is a part of a key template, either literal text or a placeholder
+type segment struct {
+	text        string
+	placeholder bool
+}
+
+type template []segment
+
+func parseTemplate(tmpl string) template {
+	var t template
+	for len(tmpl) > 0 {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[open:], '}')
+		if end < 0 {
+			break
+		}
+		if open > 0 {
+			t = append(t, segment{text: tmpl[:open]})
+		}
+		t = append(t, segment{text: tmpl[open+1 : open+end], placeholder: true})
+		tmpl = tmpl[open+end+1:]
+	}
+	if len(tmpl) > 0 {
+		t = append(t, segment{text: tmpl})
+	}
+	return t
+}
+
+// resolve returns the key for entry, or fallback when it is not JSON encoded
+func (t template) resolve(entry []byte, fallback string, now func() time.Time) string {
+	decoded := decode(entry)
+	if decoded == nil {
+		return fallback
+	}
+
+	var key strings.Builder
+	for _, seg := range t {
+		if !seg.placeholder {
+			key.WriteString(seg.text)
+			continue
+		}
+		if seg.text == "date" {
+			key.WriteString(entryDate(decoded, now))
+			continue
+		}
+
+		val, ok := lookup(decoded, seg.text)
+		if !ok {
+			val, ok = lookup(decoded, "@fields."+seg.text)
+		}
+		if !ok || len(val) == 0 {
+			val = unresolved
+		}
+		key.WriteString(val)
+	}
+	return key.String()
+}
+
+// entryDate returns the date of the @timestamp of a decoded entry,
+// falling back to the current UTC date
+func entryDate(decoded map[string]interface{}, now func() time.Time) string {
+	const layout = "2006-01-02"
+	if ts, ok := decoded["@timestamp"].(string); ok && len(ts) >= len(layout) {
+		if _, err := time.Parse(layout, ts[:len(layout)]); err == nil {
+			return ts[:len(layout)]
+		}
+	}
+	return now().UTC().Format(layout)
+}
+
+// group is the entries delivered to the same key
+type group struct {
+	key     string
+	entries [][]byte
+}
+
+// groups splits entries by the key they are delivered to, keeping their order
+func (s *Sink) groups(entries [][]byte) []group {
+	if s.template == nil {
+		return []group{{key: s.key, entries: entries}}
+	}
+
+	now := s.now
+	if now == nil {
+		now = time.Now
+	}
+
+	var groups []group
+	index := make(map[string]int)
+	for _, entry := range entries {
+		key := s.template.resolve(entry, s.key, now)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, group{key: key})
+		}
+		groups[i].entries = append(groups[i].entries, entry)
+	}
+	return groups
+}
+
+// upkeep returns the commands that cap and expire a key after a push
+func (s *Sink) upkeep(d delivery, key string) []command {
+	if _, ok := d.(publish); ok {
+		return nil
+	}
+
+	var cmds []command
+	if _, ok := d.(list); ok && s.trim > 0 {
+		cmds = append(cmds, command{name: "LTRIM", args: []interface{}{key, -s.trim, -1}})
+	}
+	if s.ttl > 0 {
+		cmds = append(cmds, command{name: "EXPIRE", args: []interface{}{key, ttlSeconds(s.ttl)}})
+	}
+	return cmds
+}
+
+// ttlSeconds rounds d up to whole seconds, as EXPIRE takes no finer resolution
+func ttlSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
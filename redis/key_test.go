@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/syllabix/logger/redis/internal/mocks"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want template
+	}{
+		{tmpl: "logs", want: template{{text: "logs"}}},
+		{
+			tmpl: "logs:{application}:{date}",
+			want: template{
+				{text: "logs:"},
+				{text: "application", placeholder: true},
+				{text: ":"},
+				{text: "date", placeholder: true},
+			},
+		},
+		{
+			tmpl: "{level}{date}",
+			want: template{
+				{text: "level", placeholder: true},
+				{text: "date", placeholder: true},
+			},
+		},
+		{tmpl: "logs:{application", want: template{{text: "logs:{application"}}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseTemplate(tt.tmpl), tt.tmpl)
+	}
+}
+
+func TestTemplate_resolve(t *testing.T) {
+	now := func() time.Time {
+		return time.Date(2021, 3, 14, 23, 30, 0, 0, time.FixedZone("", -5*3600))
+	}
+	tests := []struct {
+		name  string
+		tmpl  string
+		entry string
+		want  string
+	}{
+		{
+			name:  "fields and timestamp",
+			tmpl:  "logs:{application}:{date}",
+			entry: `{"@timestamp":"2021-02-01T10:00:00.000+0100","@fields":{"application":"billing"}}`,
+			want:  "logs:billing:2021-02-01",
+		},
+		{
+			name:  "top level before fields",
+			tmpl:  "logs:{level}",
+			entry: `{"level":"info","@fields":{"level":"debug"}}`,
+			want:  "logs:info",
+		},
+		{
+			name:  "date without timestamp",
+			tmpl:  "logs:{date}",
+			entry: `{"@message":"hello"}`,
+			want:  "logs:2021-03-15",
+		},
+		{
+			name:  "missing values",
+			tmpl:  "logs:{application}",
+			entry: `{"@fields":{"application":""}}`,
+			want:  "logs:unknown",
+		},
+		{
+			name:  "not json",
+			tmpl:  "logs:{application}",
+			entry: "INFO hello",
+			want:  "fallback",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTemplate(tt.tmpl).resolve([]byte(tt.entry), "fallback", now)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSink_groups(t *testing.T) {
+	s := NewSink(batchKey, nil, KeyTemplate("logs:{application}"))
+	a1 := []byte(`{"@fields":{"application":"a"},"n":1}`)
+	b1 := []byte(`{"@fields":{"application":"b"},"n":2}`)
+	a2 := []byte(`{"@fields":{"application":"a"},"n":3}`)
+
+	assert.Equal(t, []group{
+		{key: "logs:a", entries: [][]byte{a1, a2}},
+		{key: "logs:b", entries: [][]byte{b1}},
+	}, s.groups([][]byte{a1, b1, a2}))
+}
+
+func TestSink_upkeep(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		writes  []string
+		want    []command
+	}{
+		{
+			name:    "list",
+			options: []Option{Trim(1000), TTL(1500 * time.Millisecond)},
+			writes:  []string{"one"},
+			want: []command{
+				{name: "RPUSH", args: []interface{}{batchKey, "one"}},
+				{name: "LTRIM", args: []interface{}{batchKey, int64(-1000), -1}},
+				{name: "EXPIRE", args: []interface{}{batchKey, int64(2)}},
+			},
+		},
+		{
+			name:    "stream is not trimmed by list length",
+			options: []Option{Stream(10), Trim(1000), TTL(time.Hour)},
+			writes:  []string{"one"},
+			want: []command{
+				{name: "XADD", args: []interface{}{batchKey, "MAXLEN", "~", int64(10), "*", "entry", "one"}},
+				{name: "EXPIRE", args: []interface{}{batchKey, int64(3600)}},
+			},
+		},
+		{
+			name:    "per key",
+			options: []Option{KeyTemplate("logs:{app}"), Trim(5)},
+			writes:  []string{`{"app":"a"}`, `{"app":"b"}`},
+			want: []command{
+				{name: "RPUSH", args: []interface{}{"logs:a", `{"app":"a"}`}},
+				{name: "LTRIM", args: []interface{}{"logs:a", int64(-5), -1}},
+				{name: "RPUSH", args: []interface{}{"logs:b", `{"app":"b"}`}},
+				{name: "LTRIM", args: []interface{}{"logs:b", int64(-5), -1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := new(mocks.Conn)
+			var sent []command
+			for _, cmd := range tt.want {
+				conn.On("Send", cmd.name, cmd.args).Return(nil).Once().Run(func(args mock.Arguments) {
+					sent = append(sent, command{name: args.String(0), args: args.Get(1).([]interface{})})
+				})
+			}
+			conn.On("Do", "", []interface{}(nil)).Return(nil, nil)
+
+			s := NewSink(batchKey, newBatchPool(conn), tt.options...)
+			var entries [][]byte
+			for _, w := range tt.writes {
+				entries = append(entries, []byte(w))
+			}
+			assert.NoError(t, s.push(entries))
+			assert.Equal(t, tt.want, sent)
+		})
+	}
+}
+
+func TestSink_publishHasNoUpkeep(t *testing.T) {
+	conn := new(mocks.Conn)
+	conn.On("Do", "PUBLISH", []interface{}{batchKey, "one"}).Return(int64(1), nil)
+
+	s := NewSink(batchKey, newBatchPool(conn), Publish(), Trim(10), TTL(time.Minute))
+	assert.NoError(t, s.push([][]byte{[]byte("one")}))
+	conn.AssertExpectations(t)
+}
@@ -0,0 +1,172 @@
+package redis
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned when entries are not delivered because
+// the circuit breaker of a Sink is open
+var ErrCircuitOpen = errors.New("the redis sink circuit breaker is open")
+
+// Retry retries failed deliveries up to attempts times, waiting with an exponential
+// backoff that starts at base and doubles after every attempt, up to max. Without
+// Batch, retries are made on the goroutine that is logging
+func Retry(attempts int, base, max time.Duration) Option {
+	return func(s *Sink) {
+		s.retry = &retry{
+			attempts: attempts,
+			base:     base,
+			max:      max,
+			sleep:    time.Sleep,
+		}
+	}
+}
+
+// CircuitBreaker stops a Sink from contacting redis for the cooldown period once
+// threshold deliveries in a row have failed. Afterwards a single delivery is let
+// through to probe redis, closing the breaker again if it succeeds
+func CircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *Sink) {
+		if threshold < 1 {
+			threshold = 1
+		}
+		s.breaker = &breaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+			now:       time.Now,
+		}
+	}
+}
+
+type retry struct {
+	attempts int
+	base     time.Duration
+	max      time.Duration
+	sleep    func(time.Duration)
+}
+
+// backoff returns how long to wait before the provided retry, starting at zero
+func (r *retry) backoff(attempt int) time.Duration {
+	d := r.base << uint(attempt)
+	if d > r.max || d <= 0 {
+		return r.max
+	}
+	return d
+}
+
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a delivery may be attempted
+func (b *breaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	b.probing = true
+	return true
+}
+
+// record registers the outcome of a delivery
+func (b *breaker) record(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// deliver sends entries to redis, spilling them to disk when that fails and
+// a spill file is configured. While spilled entries are waiting to be replayed,
+// entries are spilled behind them and replayed in the background
+func (s *Sink) deliver(entries [][]byte) error {
+	count := uint64(len(entries))
+
+	if s.spill != nil {
+		appended, replay, err := s.spill.appendPending(entries)
+		if replay {
+			go s.replay()
+		}
+		if appended {
+			if err != nil {
+				atomic.AddUint64(&s.stats.Dropped, count)
+				return err
+			}
+			atomic.AddUint64(&s.stats.Spilled, count)
+			return nil
+		}
+	}
+
+	err := s.send(entries)
+	if err == nil {
+		atomic.AddUint64(&s.stats.Written, count)
+		return nil
+	}
+
+	if s.spill != nil {
+		if serr := s.spill.append(entries); serr == nil {
+			atomic.AddUint64(&s.stats.Spilled, count)
+			return nil
+		}
+	}
+
+	atomic.AddUint64(&s.stats.Dropped, count)
+	return err
+}
+
+// send pushes entries to redis, retrying as configured while
+// the circuit breaker allows it
+func (s *Sink) send(entries [][]byte) error {
+	attempts := 0
+	if s.retry != nil {
+		attempts = s.retry.attempts
+	}
+
+	var err error
+	for attempt := 0; attempt <= attempts; attempt++ {
+		if attempt > 0 {
+			atomic.AddUint64(&s.stats.Retried, uint64(len(entries)))
+			s.retry.sleep(s.retry.backoff(attempt - 1))
+		}
+
+		if s.breaker != nil && !s.breaker.allow() {
+			return ErrCircuitOpen
+		}
+
+		err = s.push(entries)
+
+		if s.breaker != nil {
+			s.breaker.record(err)
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/redis/internal/mocks"
+)
+
+var errRefused = errors.New("connection refused")
+
+func TestRetry_backoff(t *testing.T) {
+	r := &retry{base: 10 * time.Millisecond, max: 50 * time.Millisecond}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 10 * time.Millisecond},
+		{attempt: 1, want: 20 * time.Millisecond},
+		{attempt: 2, want: 40 * time.Millisecond},
+		{attempt: 3, want: 50 * time.Millisecond},
+		{attempt: 70, want: 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, r.backoff(tt.attempt), "attempt %d", tt.attempt)
+	}
+}
+
+func TestSink_Retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantErr   error
+		wantSleep []time.Duration
+		wantStats Stats
+	}{
+		{
+			name:      "recovers",
+			failures:  2,
+			wantSleep: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+			wantStats: Stats{Written: 1, Retried: 2},
+		},
+		{
+			name:      "gives up",
+			failures:  4,
+			wantErr:   errRefused,
+			wantSleep: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond},
+			wantStats: Stats{Dropped: 1, Retried: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []interface{}{batchKey, "hello"}
+			conn := new(mocks.Conn)
+			conn.On("Do", "RPUSH", args).Return(nil, errRefused).Times(tt.failures)
+			conn.On("Do", "RPUSH", args).Return(int64(1), nil)
+
+			s := NewSink(batchKey, newBatchPool(conn), Retry(3, 10*time.Millisecond, 25*time.Millisecond))
+			var slept []time.Duration
+			s.retry.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+			_, err := s.Write([]byte("hello"))
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantSleep, slept)
+			assert.Equal(t, tt.wantStats, s.Stats())
+		})
+	}
+}
+
+func TestSink_CircuitBreaker(t *testing.T) {
+	args := []interface{}{batchKey, "hello"}
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", args).Return(nil, errRefused).Times(3)
+	conn.On("Do", "RPUSH", args).Return(int64(1), nil)
+
+	now := time.Date(2020, time.March, 22, 13, 42, 12, 0, time.UTC)
+	s := NewSink(batchKey, newBatchPool(conn), CircuitBreaker(2, time.Minute))
+	s.breaker.now = func() time.Time { return now }
+
+	write := func() error {
+		_, err := s.Write([]byte("hello"))
+		return err
+	}
+
+	// the breaker opens after two failures in a row
+	assert.Equal(t, errRefused, write())
+	assert.Equal(t, errRefused, write())
+	assert.Equal(t, ErrCircuitOpen, write())
+	conn.AssertNumberOfCalls(t, "Do", 2)
+
+	// after the cooldown a failing probe opens it again
+	now = now.Add(time.Minute)
+	assert.Equal(t, errRefused, write())
+	assert.Equal(t, ErrCircuitOpen, write())
+	conn.AssertNumberOfCalls(t, "Do", 3)
+
+	// and a successful probe closes it
+	now = now.Add(time.Minute)
+	assert.NoError(t, write())
+	assert.NoError(t, write())
+	conn.AssertNumberOfCalls(t, "Do", 5)
+	assert.Equal(t, Stats{Written: 2, Dropped: 5}, s.Stats())
+}
@@ -1,9 +1,16 @@
 package redis
 
 import (
+	"errors"
+	"sync/atomic"
+	"time"
+
 	redigo "github.com/garyburd/redigo/redis"
 )
 
+// ErrClosed is returned when writing to a Sink that has been closed
+var ErrClosed = errors.New("the redis sink has been closed")
+
 // A Pool is used to retrieve pooled connections via it's Get method. When the pool
 // is no longer in use - Close should be called to ensure all resources are released
 type Pool interface {
@@ -11,17 +18,62 @@ type Pool interface {
 	Close() error
 }
 
+// An Option can be used to configure a Sink
+type Option func(s *Sink)
+
+// Stats are counters of the log entries handled by a Sink
+type Stats struct {
+	// Written is the number of entries delivered to redis
+	Written uint64
+	// Dropped is the number of entries that were lost
+	Dropped uint64
+	// Retried is the number of entries that delivery was retried for
+	Retried uint64
+	// Spilled is the number of entries written to the spill file
+	Spilled uint64
+	// Replayed is the number of spilled entries delivered to redis
+	Replayed uint64
+}
+
 // Sink can be used to sync zap logs with redis
 type Sink struct {
-	pool Pool
-	key  string
+	// stats is updated atomically, and kept first for 64 bit alignment
+	stats    Stats
+	pool     Pool
+	key      string
+	delivery delivery
+	template template
+	trim     int64
+	ttl      time.Duration
+	now      func() time.Time
+	batch    *batcher
+	retry    *retry
+	breaker  *breaker
+	spill    *spill
+
+	// the batch options, batch is built from them once all options are applied
+	batchSize     int
+	batchInterval time.Duration
+	queueSize     int
+	overflow      OverflowPolicy
+
+	// spillLimit is applied to spill once all options are applied
+	spillLimit int64
+
+	// streamFields are attached to a stream delivery once all options are applied
+	streamFields []fieldMapping
 }
 
 func (s *Sink) Write(p []byte) (n int, err error) {
-	conn := s.pool.Get()
-	defer conn.Close()
+	if s.batch != nil {
+		n, err = s.batch.add(p)
+		if err == ErrQueueFull {
+			atomic.AddUint64(&s.stats.Dropped, 1)
+		}
+		return n, err
+	}
 
-	_, err = conn.Do("RPUSH", s.key, string(p))
+	err = s.deliver([][]byte{p})
 	if err != nil {
 		return 0, err
 	}
@@ -29,17 +81,96 @@ func (s *Sink) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-// Close closes the underlying connection pull. All
-// future calls to a closed instance will fail
+// Stats returns the counters of the entries handled by the Sink
+func (s *Sink) Stats() Stats {
+	return Stats{
+		Written:  atomic.LoadUint64(&s.stats.Written),
+		Dropped:  atomic.LoadUint64(&s.stats.Dropped),
+		Retried:  atomic.LoadUint64(&s.stats.Retried),
+		Spilled:  atomic.LoadUint64(&s.stats.Spilled),
+		Replayed: atomic.LoadUint64(&s.stats.Replayed),
+	}
+}
+
+// Sync flushes all buffered log entries to redis, returning any error
+// that occurred delivering entries since the previous call to Sync
+func (s *Sink) Sync() error {
+	if s.batch != nil {
+		return s.batch.sync()
+	}
+	return nil
+}
+
+// Close flushes all buffered log entries, waits for a replay of spilled entries
+// in progress and closes the underlying connection pull. All future calls to a
+// closed instance will fail
 func (s *Sink) Close() error {
+	if s.batch != nil {
+		s.batch.close()
+	}
+	if s.spill != nil {
+		s.spill.close()
+	}
 	return s.pool.Close()
 }
 
+// push delivers entries to redis. A single command is sent as is, while
+// multiple commands are pipelined
+func (s *Sink) push(entries [][]byte) error {
+	var d delivery = list{}
+	if s.delivery != nil {
+		d = s.delivery
+	}
+
+	var cmds []command
+	for _, g := range s.groups(entries) {
+		cmds = append(cmds, d.commands(g.key, g.entries)...)
+		cmds = append(cmds, s.upkeep(d, g.key)...)
+	}
+
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	if len(cmds) == 1 {
+		_, err := conn.Do(cmds[0].name, cmds[0].args...)
+		return err
+	}
+
+	for _, cmd := range cmds {
+		if err := conn.Send(cmd.name, cmd.args...); err != nil {
+			return err
+		}
+	}
+
+	// an empty command flushes the pipeline and receives all replies
+	_, err := conn.Do("")
+	return err
+}
+
 // NewSink construct a useful instance of zap logger sync
 // that can be used to write logs to a remote redis instance
-func NewSink(key string, pool Pool) *Sink {
-	return &Sink{
+func NewSink(key string, pool Pool, options ...Option) *Sink {
+	s := &Sink{
 		pool: pool,
 		key:  key,
 	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if st, ok := s.delivery.(*stream); ok {
+		st.fields = s.streamFields
+	}
+
+	s.batch = s.newBatcher()
+	if s.batch != nil {
+		go s.batch.run()
+	}
+
+	if s.spill != nil {
+		s.initSpill()
+	}
+
+	return s
 }
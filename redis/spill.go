@@ -0,0 +1,356 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSpillFull is returned when an entry is dropped because the
+// spill file of a Sink has reached its size limit
+var ErrSpillFull = errors.New("the redis sink spill file is full")
+
+const (
+	// replayBatchSize is the number of spilled entries replayed per command
+	replayBatchSize = 100
+	// defaultSpillLimit is the default size limit of a spill file, in bytes
+	defaultSpillLimit = 64 << 20
+	// replayRetryInterval is how long a failed replay waits before trying again
+	replayRetryInterval = 10 * time.Second
+	// offsetSuffix names the file next to a spill file that records how
+	// much of it has been replayed
+	offsetSuffix = ".offset"
+)
+
+// Spill writes entries that could not be delivered to the file at path. While the
+// file holds entries, newer entries are appended behind them and the file is replayed
+// to redis in the background, so that entries are delivered in order. A failed replay
+// is retried periodically, and entries left in the file by a previous process are
+// replayed as soon as the sink is created
+func Spill(path string) Option {
+	return func(s *Sink) {
+		s.spill = &spill{path: path}
+	}
+}
+
+// SpillLimit bounds the size in bytes of the spill file, which defaults to 64MiB.
+// Entries that do not fit are dropped. It has no effect without Spill
+func SpillLimit(size int64) Option {
+	return func(s *Sink) {
+		if size > 0 {
+			s.spillLimit = size
+		}
+	}
+}
+
+// spill is a file holding one quoted entry per line. Entries are replayed from
+// offset, which is recorded next to the file, and the file is truncated once
+// all of them have been replayed
+type spill struct {
+	path  string
+	limit int64
+	// retryAfter is how long a failed replay waits before trying again
+	retryAfter time.Duration
+	// retry starts a replay once retryAfter has passed
+	retry func()
+
+	mutex     sync.Mutex
+	size      int64
+	offset    int64
+	pending   bool
+	replaying bool
+	closed    bool
+	timer     *time.Timer
+	// replays tracks the replay in progress, waited on when closing the sink
+	replays sync.WaitGroup
+}
+
+// initSpill applies the spill options and starts replaying
+// the entries left by a previous process
+func (s *Sink) initSpill() {
+	sp := s.spill
+	sp.limit = s.spillLimit
+	if sp.limit == 0 {
+		sp.limit = defaultSpillLimit
+	}
+	if sp.retryAfter == 0 {
+		sp.retryAfter = replayRetryInterval
+	}
+	sp.retry = s.retryReplay
+
+	if sp.recover() {
+		sp.mutex.Lock()
+		replay := sp.start()
+		sp.mutex.Unlock()
+		if replay {
+			go s.replay()
+		}
+	}
+}
+
+// recover picks up the entries left in the file by a previous process, along
+// with how much of it was replayed, and reports whether any are pending
+func (sp *spill) recover() bool {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	info, err := os.Stat(sp.path)
+	if err != nil || info.Size() == 0 {
+		return false
+	}
+	sp.size = info.Size()
+
+	if !endsWithNewline(sp.path, sp.size) {
+		// terminate a partially written line so that it is skipped on its own
+		if f, err := os.OpenFile(sp.path, os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+			if n, err := f.Write([]byte{'\n'}); err == nil {
+				sp.size += int64(n)
+			}
+			f.Close()
+		}
+	}
+
+	if data, err := ioutil.ReadFile(sp.path + offsetSuffix); err == nil {
+		offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err == nil && offset > 0 && offset <= sp.size {
+			sp.offset = offset
+		}
+	}
+
+	sp.pending = sp.offset < sp.size
+	return sp.pending
+}
+
+// endsWithNewline reports whether the last byte of the file at path is a newline
+func endsWithNewline(path string, size int64) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, size-1); err != nil {
+		return true
+	}
+	return last[0] == '\n'
+}
+
+// append spills entries that could not be delivered, and schedules a replay
+func (sp *spill) append(entries [][]byte) error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if err := sp.write(entries); err != nil {
+		return err
+	}
+	sp.schedule()
+	return nil
+}
+
+// appendPending appends entries behind those waiting to be replayed, and reports
+// whether it did so and whether the caller should start a replay
+func (sp *spill) appendPending(entries [][]byte) (appended, replay bool, err error) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if !sp.pending {
+		return false, false, nil
+	}
+	if err := sp.write(entries); err != nil {
+		return true, false, err
+	}
+	return true, sp.start(), nil
+}
+
+// write appends entries to the file. The caller must hold the mutex
+func (sp *spill) write(entries [][]byte) error {
+	var buf strings.Builder
+	for _, entry := range entries {
+		buf.WriteString(strconv.Quote(string(entry)))
+		buf.WriteByte('\n')
+	}
+	if sp.limit > 0 && sp.size+int64(buf.Len()) > sp.limit {
+		return ErrSpillFull
+	}
+
+	f, err := os.OpenFile(sp.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.WriteString(f, buf.String())
+	sp.size += int64(n)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	sp.pending = true
+	return f.Close()
+}
+
+// start marks a replay as started unless one is running or the sink is closed,
+// and reports whether the caller should run it. The caller must hold the mutex
+func (sp *spill) start() bool {
+	if sp.replaying || sp.closed {
+		return false
+	}
+	if sp.timer != nil {
+		sp.timer.Stop()
+		sp.timer = nil
+	}
+	sp.replaying = true
+	sp.replays.Add(1)
+	return true
+}
+
+// schedule retries a replay of pending entries once retryAfter has passed,
+// unless one is running or scheduled already. The caller must hold the mutex
+func (sp *spill) schedule() {
+	if !sp.pending || sp.replaying || sp.closed || sp.timer != nil {
+		return
+	}
+	sp.timer = time.AfterFunc(sp.retryAfter, sp.retry)
+}
+
+// advance records that the entries up to offset have been replayed, truncating
+// the file once all of them have been. The caller must hold the mutex
+func (sp *spill) advance(offset int64) {
+	sp.offset = offset
+	if sp.offset < sp.size {
+		ioutil.WriteFile(sp.path+offsetSuffix, []byte(strconv.FormatInt(offset, 10)), 0644)
+		return
+	}
+
+	if err := os.Truncate(sp.path, 0); err != nil {
+		ioutil.WriteFile(sp.path+offsetSuffix, []byte(strconv.FormatInt(offset, 10)), 0644)
+		return
+	}
+	os.Remove(sp.path + offsetSuffix)
+	sp.size = 0
+	sp.offset = 0
+	sp.pending = false
+}
+
+// close prevents further replays and waits for the one in progress
+func (sp *spill) close() {
+	sp.mutex.Lock()
+	sp.closed = true
+	if sp.timer != nil {
+		sp.timer.Stop()
+		sp.timer = nil
+	}
+	sp.mutex.Unlock()
+
+	sp.replays.Wait()
+}
+
+// retryReplay starts a replay scheduled after a failed one
+func (s *Sink) retryReplay() {
+	sp := s.spill
+	sp.mutex.Lock()
+	sp.timer = nil
+	replay := sp.pending && sp.start()
+	sp.mutex.Unlock()
+
+	if replay {
+		s.replay()
+	}
+}
+
+// replay delivers spilled entries in order until all of them are delivered or
+// a delivery fails, in which case it is retried later. The mutex is only held
+// to update the offset, so that entries can be spilled while it runs
+func (s *Sink) replay() {
+	sp := s.spill
+	defer sp.replays.Done()
+
+	for {
+		sp.mutex.Lock()
+		offset, size := sp.offset, sp.size
+		sp.mutex.Unlock()
+
+		err := s.replayFrom(offset)
+
+		sp.mutex.Lock()
+		if err != nil || sp.offset == offset && sp.size == size {
+			// stop on failure, as well as when nothing could be replayed
+			// and nothing was spilled since
+			sp.replaying = false
+			sp.schedule()
+			sp.mutex.Unlock()
+			return
+		}
+		if !sp.pending {
+			sp.replaying = false
+			sp.mutex.Unlock()
+			return
+		}
+		sp.mutex.Unlock()
+	}
+}
+
+// replayFrom streams the spilled entries from offset to redis, in batches
+func (s *Sink) replayFrom(offset int64) error {
+	sp := s.spill
+
+	f, err := os.Open(sp.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(f)
+
+	for eof := false; !eof; {
+		var entries [][]byte
+		read := offset
+		for len(entries) < replayBatchSize {
+			line, err := r.ReadBytes('\n')
+			if err == io.EOF {
+				// a line without a newline is still being written, and is
+				// read again by the next pass
+				eof = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			read += int64(len(line))
+
+			entry, err := strconv.Unquote(string(line[:len(line)-1]))
+			if err != nil {
+				// skip lines that were only partially written
+				continue
+			}
+			entries = append(entries, []byte(entry))
+		}
+
+		if read == offset {
+			return nil
+		}
+		if len(entries) > 0 {
+			if err := s.send(entries); err != nil {
+				return err
+			}
+			atomic.AddUint64(&s.stats.Replayed, uint64(len(entries)))
+		}
+
+		sp.mutex.Lock()
+		sp.advance(read)
+		sp.mutex.Unlock()
+		offset = read
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package redis
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/syllabix/logger/redis/internal/mocks"
+)
+
+func tempSpill(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "redis-spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "spill.log"), func() { os.RemoveAll(dir) }
+}
+
+// replayRetry sets how long a failed replay waits, and must follow Spill
+func replayRetry(d time.Duration) Option {
+	return func(s *Sink) {
+		s.spill.retryAfter = d
+	}
+}
+
+// waitForStats waits until the stats of s satisfy cond
+func waitForStats(t *testing.T, s *Sink, cond func(Stats) bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond(s.Stats()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for stats, got %+v", s.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSink_Spill(t *testing.T) {
+	path, cleanup := tempSpill(t)
+	defer cleanup()
+
+	multiline := "ERROR stacktrace=main.go:12\n\tmain.go:40"
+
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", []interface{}{batchKey, multiline}).Return(nil, errRefused).Once()
+	conn.On("Do", "RPUSH", []interface{}{batchKey, multiline, "second"}).Return(nil, errRefused).Once()
+	conn.On("Do", "RPUSH", []interface{}{batchKey, multiline, "second", "third"}).Return(int64(3), nil).Once()
+
+	s := NewSink(batchKey, newBatchPool(conn), Spill(path), replayRetry(time.Hour))
+
+	// undeliverable entries are spilled rather than reported, and entries
+	// written while some are spilled are appended behind them
+	for _, entry := range []string{multiline, "second"} {
+		n, err := s.Write([]byte(entry))
+		assert.NoError(t, err)
+		assert.Equal(t, len(entry), n)
+	}
+	s.spill.replays.Wait()
+	assert.Equal(t, Stats{Spilled: 2}, s.Stats())
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "\"ERROR stacktrace=main.go:12\\n\\tmain.go:40\"\n\"second\"\n", string(contents))
+
+	// once redis is back, spilled entries are replayed ahead of the new one
+	_, err = s.Write([]byte("third"))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Close())
+	assert.Equal(t, Stats{Spilled: 3, Replayed: 3}, s.Stats())
+
+	contents, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Empty(t, contents)
+	assert.False(t, exists(path+offsetSuffix))
+	conn.AssertExpectations(t)
+}
+
+func TestSink_SpillReplaysInBackground(t *testing.T) {
+	path, cleanup := tempSpill(t)
+	defer cleanup()
+
+	err := ioutil.WriteFile(path, []byte("\"first\"\n"), 0644)
+	assert.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "first"}).Return(int64(1), nil).Once().Run(func(mock.Arguments) {
+		close(started)
+		<-release
+	})
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "second"}).Return(int64(1), nil).Once()
+
+	// entries left by a previous process are replayed right away
+	s := NewSink(batchKey, newBatchPool(conn), Spill(path))
+	waitFor(t, started)
+
+	// writing does not wait for the replay in progress
+	written := make(chan struct{})
+	go func() {
+		s.Write([]byte("second"))
+		close(written)
+	}()
+	waitFor(t, written)
+
+	// entries spilled during the replay are replayed after it
+	close(release)
+	assert.NoError(t, s.Close())
+	assert.Equal(t, Stats{Spilled: 1, Replayed: 2}, s.Stats())
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Empty(t, contents)
+	conn.AssertExpectations(t)
+}
+
+func TestSink_SpillRetriesWhenIdle(t *testing.T) {
+	path, cleanup := tempSpill(t)
+	defer cleanup()
+
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "one"}).Return(nil, errRefused).Twice()
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "one"}).Return(int64(1), nil).Once()
+
+	s := NewSink(batchKey, newBatchPool(conn), Spill(path), replayRetry(time.Millisecond))
+	_, err := s.Write([]byte("one"))
+	assert.NoError(t, err)
+
+	// the replay is retried until redis recovers, without further writes
+	waitForStats(t, s, func(st Stats) bool { return st.Replayed == 1 })
+	assert.NoError(t, s.Close())
+	assert.Equal(t, Stats{Spilled: 1, Replayed: 1}, s.Stats())
+	conn.AssertExpectations(t)
+}
+
+func TestSink_SpillLimit(t *testing.T) {
+	path, cleanup := tempSpill(t)
+	defer cleanup()
+
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "one"}).Return(nil, errRefused).Once()
+
+	// each entry takes 6 bytes: quoted and followed by a newline
+	s := NewSink(batchKey, newBatchPool(conn), SpillLimit(10), Spill(path), replayRetry(time.Hour))
+	_, err := s.Write([]byte("one"))
+	assert.NoError(t, err)
+
+	n, err := s.Write([]byte("two"))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, ErrSpillFull, err)
+	assert.Equal(t, Stats{Spilled: 1, Dropped: 1}, s.Stats())
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "\"one\"\n", string(contents))
+	conn.AssertExpectations(t)
+}
+
+func TestSink_SpillResumesFromOffset(t *testing.T) {
+	path, cleanup := tempSpill(t)
+	defer cleanup()
+
+	var spilled []byte
+	var rest []interface{}
+	for i := 0; i < replayBatchSize+3; i++ {
+		entry := strconv.Itoa(i)
+		spilled = append(spilled, strconv.Quote(entry)+"\n"...)
+		if i >= replayBatchSize {
+			rest = append(rest, entry)
+		}
+	}
+	err := ioutil.WriteFile(path, spilled, 0644)
+	assert.NoError(t, err)
+
+	// the first batch is delivered before redis goes away
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", mock.Anything).Return(int64(replayBatchSize), nil).Once()
+	conn.On("Do", "RPUSH", mock.Anything).Return(nil, errRefused).Once()
+
+	s := NewSink(batchKey, newBatchPool(conn), Spill(path), replayRetry(time.Hour))
+	waitForStats(t, s, func(st Stats) bool { return st.Replayed == replayBatchSize })
+	assert.NoError(t, s.Close())
+	conn.AssertExpectations(t)
+
+	// the next process only replays what was not delivered
+	conn = new(mocks.Conn)
+	conn.On("Do", "RPUSH", append([]interface{}{batchKey}, rest...)).Return(int64(3), nil).Once()
+
+	s = NewSink(batchKey, newBatchPool(conn), Spill(path))
+	assert.NoError(t, s.Close())
+	assert.Equal(t, Stats{Replayed: 3}, s.Stats())
+	assert.False(t, exists(path+offsetSuffix))
+	conn.AssertExpectations(t)
+}
+
+func TestSink_SpillFromPreviousProcess(t *testing.T) {
+	path, cleanup := tempSpill(t)
+	defer cleanup()
+
+	// a partially written trailing line is skipped
+	err := ioutil.WriteFile(path, []byte("\"left over\"\n\"trunc"), 0644)
+	assert.NoError(t, err)
+
+	conn := new(mocks.Conn)
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "left over"}).Return(int64(1), nil).Once()
+	conn.On("Do", "RPUSH", []interface{}{batchKey, "fresh"}).Return(int64(1), nil).Once()
+
+	s := NewSink(batchKey, newBatchPool(conn), Spill(path))
+	s.spill.replays.Wait()
+
+	_, err = s.Write([]byte("fresh"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, Stats{Written: 1, Replayed: 1}, s.Stats())
+	conn.AssertExpectations(t)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
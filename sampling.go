@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/syllabix/logger/internal/registry"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingPolicy limits how many entries with the same level and message are
+// logged per Tick. The First entries are logged, and after that only every
+// Thereafter entry, or none at all when Thereafter is zero
+type SamplingPolicy struct {
+	First      int
+	Thereafter int
+	// Tick defaults to one second
+	Tick time.Duration
+}
+
+// Sampling enables sampling for all logger instances, with a policy per level.
+// Levels without a policy are not sampled, and passing nil turns sampling off,
+// so it can be enabled in production while every entry is kept in development
+func Sampling(policies map[zapcore.Level]SamplingPolicy) Option {
+	return func(config *Config) {
+		config.sampling = policies
+	}
+}
+
+// SamplingForPackage replaces the sampling policies of logger instances in the
+// provided package, or import path prefix, and its descendants. Like levels, the
+// override of the nearest prefix applies. Passing nil turns sampling off for them
+func SamplingForPackage(pkg string, policies map[zapcore.Level]SamplingPolicy) Option {
+	return func(config *Config) {
+		if config.pkgsampling == nil {
+			config.pkgsampling = make(map[registry.Package]map[zapcore.Level]SamplingPolicy)
+		}
+		config.pkgsampling[registry.Package(pkg)] = policies
+	}
+}
+
+// OnSampled sets a func that is called for every entry dropped by sampling, with
+// the number of entries with the same level and message dropped during the current
+// tick. It is called synchronously on the logging goroutine, so it should be quick
+func OnSampled(fn func(ent zapcore.Entry, dropped uint64)) Option {
+	return func(config *Config) {
+		config.onSampled = fn
+	}
+}
+
+const (
+	// sampledLevels is the number of levels from debug to fatal
+	sampledLevels = int(zapcore.FatalLevel-zapcore.DebugLevel) + 1
+	// countersPerLevel is the number of message counters of a sampled level.
+	// Messages are hashed into them, so distinct messages may share a counter
+	countersPerLevel = 4096
+)
+
+// counter counts the entries with the same message during a tick
+type counter struct {
+	resetAt int64
+	count   uint64
+}
+
+// inc increments the counter, resetting it first when its tick has passed
+func (c *counter) inc(t time.Time, tick time.Duration) uint64 {
+	now := t.UnixNano()
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if resetAt > now {
+		return atomic.AddUint64(&c.count, 1)
+	}
+
+	atomic.StoreUint64(&c.count, 1)
+	if !atomic.CompareAndSwapInt64(&c.resetAt, resetAt, now+tick.Nanoseconds()) {
+		// another goroutine reset the counter first
+		return atomic.AddUint64(&c.count, 1)
+	}
+	return 1
+}
+
+type levelSampler struct {
+	policy   SamplingPolicy
+	counters [countersPerLevel]counter
+}
+
+// sampler decides which entries are logged for a set of sampling policies. It
+// is kept per generation, so counters start over when Configure is called
+type sampler struct {
+	levels [sampledLevels]*levelSampler
+	hook   func(zapcore.Entry, uint64)
+}
+
+// newSampler returns a sampler for policies, or nil if nothing is sampled
+func newSampler(policies map[zapcore.Level]SamplingPolicy, hook func(zapcore.Entry, uint64)) *sampler {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	s := &sampler{hook: hook}
+	for lvl, policy := range policies {
+		i := int(lvl - zapcore.DebugLevel)
+		if i < 0 || i >= sampledLevels {
+			continue
+		}
+		if policy.Tick <= 0 {
+			policy.Tick = time.Second
+		}
+		s.levels[i] = &levelSampler{policy: policy}
+	}
+	return s
+}
+
+// sample reports whether ent should be logged
+func (s *sampler) sample(ent zapcore.Entry) bool {
+	i := int(ent.Level - zapcore.DebugLevel)
+	if i < 0 || i >= sampledLevels || s.levels[i] == nil {
+		return true
+	}
+
+	ls := s.levels[i]
+	n := ls.counters[hash(ent.Message)%countersPerLevel].inc(ent.Time, ls.policy.Tick)
+
+	first, thereafter := uint64(ls.policy.First), uint64(ls.policy.Thereafter)
+	if n <= first {
+		return true
+	}
+	if thereafter > 0 && (n-first)%thereafter == 0 {
+		return true
+	}
+
+	if s.hook != nil {
+		kept := first
+		if thereafter > 0 {
+			kept += (n - first) / thereafter
+		}
+		s.hook(ent, n-kept)
+	}
+	return false
+}
+
+// hash is the 32 bit FNV-1a hash of a message
+func hash(msg string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(msg); i++ {
+		h ^= uint32(msg[i])
+		h *= prime32
+	}
+	return h
+}
+
+// samplers are the samplers of a generation: the default one and those of the
+// packages, or import path prefixes, with an override
+type samplers struct {
+	standard  *sampler
+	overrides map[registry.Package]*sampler
+}
+
+func newSamplers(config *Config) samplers {
+	s := samplers{
+		standard:  newSampler(config.sampling, config.onSampled),
+		overrides: make(map[registry.Package]*sampler, len(config.pkgsampling)),
+	}
+	for pkg, policies := range config.pkgsampling {
+		s.overrides[pkg] = newSampler(policies, config.onSampled)
+	}
+	return s
+}
+
+// forPackage returns the sampler of the nearest override of pkg, falling back
+// to the default one. It returns nil when entries of pkg are not sampled
+func (s samplers) forPackage(pkg registry.Package) *sampler {
+	if len(s.overrides) > 0 {
+		for _, p := range pkg.Lineage() {
+			if override, ok := s.overrides[p]; ok {
+				return override
+			}
+		}
+	}
+	return s.standard
+}
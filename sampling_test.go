@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSampler_sample(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      SamplingPolicy
+		wantKept    []bool
+		wantDropped []uint64
+	}{
+		{
+			name:        "first and thereafter",
+			policy:      SamplingPolicy{First: 2, Thereafter: 3},
+			wantKept:    []bool{true, true, false, false, true, false, false, true},
+			wantDropped: []uint64{1, 2, 3, 4},
+		},
+		{
+			name:        "none thereafter",
+			policy:      SamplingPolicy{First: 1},
+			wantKept:    []bool{true, false, false, false},
+			wantDropped: []uint64{1, 2, 3},
+		},
+		{
+			name:        "every other entry",
+			policy:      SamplingPolicy{Thereafter: 2},
+			wantKept:    []bool{false, true, false, true},
+			wantDropped: []uint64{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dropped []uint64
+			s := newSampler(map[zapcore.Level]SamplingPolicy{zap.InfoLevel: tt.policy}, func(ent zapcore.Entry, n uint64) {
+				assert.Equal(t, "flood", ent.Message)
+				dropped = append(dropped, n)
+			})
+
+			now := time.Now()
+			var kept []bool
+			for range tt.wantKept {
+				kept = append(kept, s.sample(zapcore.Entry{Level: zap.InfoLevel, Message: "flood", Time: now}))
+			}
+			assert.Equal(t, tt.wantKept, kept)
+			assert.Equal(t, tt.wantDropped, dropped)
+		})
+	}
+}
+
+func TestSampler_tick(t *testing.T) {
+	s := newSampler(map[zapcore.Level]SamplingPolicy{
+		zap.InfoLevel: {First: 1, Tick: time.Minute},
+	}, nil)
+
+	now := time.Now()
+	entry := func(msg string, at time.Time) zapcore.Entry {
+		return zapcore.Entry{Level: zap.InfoLevel, Message: msg, Time: at}
+	}
+
+	assert.True(t, s.sample(entry("a", now)))
+	assert.False(t, s.sample(entry("a", now.Add(time.Second))))
+	assert.True(t, s.sample(entry("b", now.Add(time.Second))), "messages are counted separately")
+	assert.True(t, s.sample(entry("a", now.Add(time.Minute))), "counters reset every tick")
+	assert.True(t, s.sample(zapcore.Entry{Level: zap.WarnLevel, Message: "a", Time: now}), "levels without a policy are not sampled")
+}
+
+func TestNewSampler_disabled(t *testing.T) {
+	assert.Nil(t, newSampler(nil, nil))
+	assert.Nil(t, newSampler(map[zapcore.Level]SamplingPolicy{}, nil))
+}
+
+func TestSamplers_forPackage(t *testing.T) {
+	policies := map[zapcore.Level]SamplingPolicy{zap.InfoLevel: {First: 1}}
+	s := newSamplers(&Config{
+		sampling: policies,
+		pkgsampling: map[registry.Package]map[zapcore.Level]SamplingPolicy{
+			"github.com/acme/db":    policies,
+			"github.com/acme/debug": nil,
+		},
+	})
+
+	assert.Equal(t, s.standard, s.forPackage("github.com/acme/http"))
+	assert.NotNil(t, s.forPackage("github.com/acme/db/sql"))
+	assert.Equal(t, s.overrides["github.com/acme/db"], s.forPackage("github.com/acme/db/sql"))
+	assert.Nil(t, s.forPackage("github.com/acme/debug"))
+}
+
+func TestSampling(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	out := new(bytes.Buffer)
+	var dropped uint64
+	Configure(
+		ConsoleWriter(out),
+		Mode(mode.Production),
+		Sampling(map[zapcore.Level]SamplingPolicy{
+			zap.InfoLevel: {First: 2, Thereafter: 5},
+		}),
+		OnSampled(func(ent zapcore.Entry, n uint64) {
+			dropped = n
+		}),
+	)
+
+	log := New().With(zap.String("request", "abc"))
+	for i := 0; i < 10; i++ {
+		log.Info("flood")
+	}
+	log.Warn("flood")
+	assert.Equal(t, 4, strings.Count(out.String(), "message=flood"))
+	assert.Equal(t, uint64(7), dropped)
+
+	// sampling is turned off for the package of this test
+	out.Reset()
+	Configure(SamplingForPackage("github.com/syllabix", nil))
+	for i := 0; i < 10; i++ {
+		log.Info("flood")
+	}
+	assert.Equal(t, 10, strings.Count(out.String(), "message=flood"))
+}
@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"io"
+	"sort"
+
+	"github.com/syllabix/logger/console"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/logfmt"
+	"go.uber.org/zap/zapcore"
+)
+
+// An Encoding builds the encoder of a sink whenever Configure is called, so
+// that it reflects the current mode and encoder keys
+type Encoding func() zapcore.Encoder
+
+// ConsoleEncoding encodes entries like the console writer does. As the
+// writer of a sink is not inspected, entries are only colored when colors
+// are forced, with ConsoleColors or the FORCE_COLOR environment variable
+func ConsoleEncoding() Encoding {
+	return func() zapcore.Encoder {
+		return console.NewEncoder(consoleConfig(nil))
+	}
+}
+
+// JSONEncoding encodes entries like the json writer does, in the schema
+// selected with JSONSchema
+func JSONEncoding() Encoding {
+	return func() zapcore.Encoder {
+		return jsonEncoder()
+	}
+}
+
+// LogfmtEncoding encodes entries in logfmt, with the keys of
+// encode.LogfmtConfig and those set with the Keys option
+func LogfmtEncoding() Encoding {
+	return func() zapcore.Encoder {
+		return logfmt.NewEncoder(logfmtConfig())
+	}
+}
+
+// CustomEncoding encodes entries with a clone of the provided encoder
+func CustomEncoding(enc zapcore.Encoder) Encoding {
+	return enc.Clone
+}
+
+// A SinkOption configures a sink added with the Sink option
+type SinkOption func(s *sinkConfig)
+
+// SinkLevel sets the minimum level of entries written to a sink. Entries must
+// also be enabled by the level of the package they are logged in
+func SinkLevel(lvl zapcore.Level) SinkOption {
+	return func(s *sinkConfig) {
+		s.level = lvl
+	}
+}
+
+// SinkPackages restricts a sink to entries logged by logger instances in the
+// provided packages, or import path prefixes, and their descendants
+func SinkPackages(pkgs ...string) SinkOption {
+	return func(s *sinkConfig) {
+		s.packages = make([]registry.Package, len(pkgs))
+		for i, pkg := range pkgs {
+			s.packages[i] = registry.Package(pkg)
+		}
+	}
+}
+
+// Sink adds an output named name to all logger instances, in addition to the
+// console and json writers. Entries are encoded with enc and written to w, and
+// by default all entries enabled by the level of their package are written.
+// Adding a sink with the name of an existing one replaces it
+func Sink(name string, w io.Writer, enc Encoding, options ...SinkOption) Option {
+	return func(config *Config) {
+		s := sinkConfig{
+			writer: w,
+			enc:    enc,
+			level:  zapcore.DebugLevel,
+		}
+		for _, opt := range options {
+			opt(&s)
+		}
+
+		// copy the sinks, so configs that were copied before are left untouched
+		sinks := make(map[string]sinkConfig, len(config.sinks)+1)
+		for n, existing := range config.sinks {
+			sinks[n] = existing
+		}
+		sinks[name] = s
+		config.sinks = sinks
+	}
+}
+
+// RemoveSink removes the sink named name from all logger instances
+func RemoveSink(name string) Option {
+	return func(config *Config) {
+		if _, ok := config.sinks[name]; !ok {
+			return
+		}
+		sinks := make(map[string]sinkConfig, len(config.sinks))
+		for n, existing := range config.sinks {
+			if n != name {
+				sinks[n] = existing
+			}
+		}
+		config.sinks = sinks
+	}
+}
+
+// sinkConfig is a sink added with the Sink option
+type sinkConfig struct {
+	writer   io.Writer
+	enc      Encoding
+	level    zapcore.Level
+	packages []registry.Package
+}
+
+// filteredCore is the core of a sink restricted to some packages, without
+// the base fields applied
+type filteredCore struct {
+	sinkCore
+	packages []registry.Package
+}
+
+// matches reports whether entries of pkg are written to the sink
+func (f filteredCore) matches(pkg registry.Package) bool {
+	for _, p := range pkg.Lineage() {
+		for _, allowed := range f.packages {
+			if p == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildSinks returns the cores of the named sinks in the global config, in
+// order of their names, split into those for all packages and those filtered
+func buildSinks() (cores []sinkCore, filtered []filteredCore) {
+	names := make([]string, 0, len(global.sinks))
+	for name := range global.sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := global.sinks[name]
+		if s.writer == nil || s.enc == nil {
+			continue
+		}
+
+		core := newSinkCore(s.enc(), s.writer, s.level)
+		if len(s.packages) == 0 {
+			cores = append(cores, core)
+			continue
+		}
+		filtered = append(filtered, filteredCore{sinkCore: core, packages: s.packages})
+	}
+	return cores, filtered
+}
@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSink(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	stdout := new(bytes.Buffer)
+	errs := new(bytes.Buffer)
+	all := new(bytes.Buffer)
+	custom := new(bytes.Buffer)
+
+	Configure(
+		Mode(mode.Production),
+		Level(zap.DebugLevel),
+		ConsoleWriter(nil),
+		Sink("stdout", stdout, ConsoleEncoding(), SinkLevel(zap.InfoLevel)),
+		Sink("errors", errs, JSONEncoding(), SinkLevel(zap.ErrorLevel)),
+		Sink("file", all, JSONEncoding()),
+		Sink("custom", custom, CustomEncoding(zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+			MessageKey: "msg",
+		}))),
+	)
+
+	log := New().With(zap.String("request", "abc"))
+	log.Debug("debug entry")
+	log.Info("info entry")
+	log.Error("error entry")
+
+	assert.NotContains(t, stdout.String(), "debug entry")
+	assert.Contains(t, stdout.String(), "message=info entry")
+	assert.Contains(t, stdout.String(), "message=error entry")
+
+	assert.NotContains(t, errs.String(), "info entry")
+	assert.Contains(t, errs.String(), `"@message":"error entry"`)
+	assert.Contains(t, errs.String(), `"request":"abc"`)
+
+	assert.Contains(t, all.String(), `"@message":"debug entry"`)
+	assert.Contains(t, all.String(), `"@message":"error entry"`)
+
+	assert.Contains(t, custom.String(), "info entry\t")
+
+	// replacing and removing sinks applies to existing loggers
+	stdout.Reset()
+	errs.Reset()
+	Configure(
+		Sink("stdout", stdout, ConsoleEncoding(), SinkLevel(zap.ErrorLevel)),
+		RemoveSink("errors"),
+	)
+	log.Info("after info")
+	log.Error("after error")
+	assert.NotContains(t, stdout.String(), "after info")
+	assert.Contains(t, stdout.String(), "message=after error")
+	assert.Empty(t, errs.String())
+}
+
+func TestSinkPackages(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	matched := new(bytes.Buffer)
+	other := new(bytes.Buffer)
+
+	Configure(
+		Mode(mode.Production),
+		ConsoleWriter(nil),
+		Sink("matched", matched, ConsoleEncoding(), SinkPackages("github.com/acme", "github.com/syllabix")),
+		Sink("other", other, ConsoleEncoding(), SinkPackages("github.com/syllabix/logger/redis")),
+	)
+
+	New().Info("from the logger package")
+	assert.Contains(t, matched.String(), "message=from the logger package")
+	assert.Empty(t, other.String())
+}
+
+func TestFilteredCore_matches(t *testing.T) {
+	f := filteredCore{packages: []registry.Package{"github.com/acme/db", "main"}}
+	tests := []struct {
+		pkg  registry.Package
+		want bool
+	}{
+		{pkg: "github.com/acme/db", want: true},
+		{pkg: "github.com/acme/db/sql", want: true},
+		{pkg: "github.com/acme/dbx", want: false},
+		{pkg: "github.com/acme", want: false},
+		{pkg: "main", want: true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, f.matches(tt.pkg), string(tt.pkg))
+	}
+}
+
+func TestRemoveSink_leavesCopiesAlone(t *testing.T) {
+	cfg := new(Config)
+	Sink("a", new(bytes.Buffer), JSONEncoding())(cfg)
+	Sink("b", new(bytes.Buffer), JSONEncoding())(cfg)
+
+	copied := *cfg
+	RemoveSink("a")(cfg)
+	Sink("c", new(bytes.Buffer), JSONEncoding())(cfg)
+
+	assert.Len(t, copied.sinks, 2)
+	assert.Contains(t, copied.sinks, "a")
+	assert.Len(t, cfg.sinks, 2)
+	assert.NotContains(t, cfg.sinks, "a")
+}
+
+func TestLogfmtEncoding(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	console := new(bytes.Buffer)
+	file := new(bytes.Buffer)
+	Configure(
+		Mode(mode.Development),
+		AppName("awesome-app"),
+		ConsoleWriter(console),
+		ConsoleFormat(LogfmtEncoding()),
+		Sink("file", file, LogfmtEncoding()),
+	)
+
+	New().With(zap.Namespace("http")).Info("request served", zap.String("path", "/a b"))
+
+	for _, written := range []string{console.String(), file.String()} {
+		assert.Contains(t, written, `level=info caller=logger/sinks_test.go:`)
+		assert.Contains(t, written, `msg="request served" host=`)
+		assert.Contains(t, written, `application=awesome-app http.path="/a b"`)
+		assert.NotContains(t, written, "\x1b[")
+	}
+
+	// a nil format restores the console encoder
+	console.Reset()
+	Configure(ConsoleFormat(nil), Mode(mode.Production))
+	New().Info("console again")
+	assert.Contains(t, console.String(), "message=console again")
+}
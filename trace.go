@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/syllabix/logger/encode"
+	"go.uber.org/zap"
+)
+
+// TraceparentHeader is the W3C trace context header read by Middleware
+const TraceparentHeader = "traceparent"
+
+// ErrInvalidTraceparent is returned when parsing a malformed traceparent
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// A TraceExtractor returns the ids of the trace and span that ctx belongs to.
+// It should return false when ctx does not carry a trace
+type TraceExtractor func(ctx context.Context) (traceID, spanID string, ok bool)
+
+// TraceFrom sets the func FromContext uses to find the trace of a context, so
+// that entries carry trace_id and span_id fields. By default the traceparent
+// added by WithTraceparent, or by Middleware, is used. A tracing library can be
+// plugged in without this module depending on it, for example with OpenTelemetry:
+//
+//	logger.TraceFrom(func(ctx context.Context) (string, string, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.SpanID().String(), sc.IsValid()
+//	})
+func TraceFrom(fn TraceExtractor) Option {
+	return func(config *Config) {
+		config.traceExtractor = fn
+	}
+}
+
+// traceKey is the key of the trace stored in a context
+type traceKey struct{}
+
+// WithTraceparent returns a copy of ctx carrying the trace of a W3C traceparent
+// header value. If the value is malformed, ctx is returned as is
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	traceID, spanID, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, traceKey{}, encode.Trace{TraceID: traceID, SpanID: spanID})
+}
+
+// ParseTraceparent returns the trace and span ids of a W3C traceparent header
+// value, such as 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(traceparent string) (traceID, spanID string, err error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return "", "", ErrInvalidTraceparent
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	switch {
+	case !isHex(version, 2) || version == "ff":
+		return "", "", ErrInvalidTraceparent
+	case version == "00" && len(parts) != 4:
+		// later versions may append fields, but version 00 has exactly four
+		return "", "", ErrInvalidTraceparent
+	case !isHex(traceID, 32) || isZero(traceID):
+		return "", "", ErrInvalidTraceparent
+	case !isHex(spanID, 16) || isZero(spanID):
+		return "", "", ErrInvalidTraceparent
+	case !isHex(flags, 2):
+		return "", "", ErrInvalidTraceparent
+	}
+	return traceID, spanID, nil
+}
+
+// isHex reports whether s consists of n lowercase hex digits
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// traceparentFromContext is the default TraceExtractor
+func traceparentFromContext(ctx context.Context) (traceID, spanID string, ok bool) {
+	t, ok := ctx.Value(traceKey{}).(encode.Trace)
+	return t.TraceID, t.SpanID, ok
+}
+
+// traceFields returns the trace field for ctx, if it belongs to a trace
+func traceFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	extract := loadGeneration().extractTrace
+	traceID, spanID, ok := extract(ctx)
+	if !ok || len(traceID) == 0 {
+		return nil
+	}
+	return []zap.Field{encode.TraceField(encode.Trace{TraceID: traceID, SpanID: spanID})}
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+	"github.com/syllabix/logger/mode"
+	"go.uber.org/zap"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID      = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testSpanID + "-01"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		wantErr     bool
+	}{
+		{name: "valid", traceparent: testTraceparent},
+		{name: "not sampled", traceparent: "00-" + testTraceID + "-" + testSpanID + "-00"},
+		{name: "future version", traceparent: "cc-" + testTraceID + "-" + testSpanID + "-01-what-the-future-holds"},
+		{name: "empty", traceparent: "", wantErr: true},
+		{name: "invalid version", traceparent: "ff-" + testTraceID + "-" + testSpanID + "-01", wantErr: true},
+		{name: "extra fields in version 00", traceparent: testTraceparent + "-extra", wantErr: true},
+		{name: "uppercase", traceparent: "00-" + strings.ToUpper(testTraceID) + "-" + testSpanID + "-01", wantErr: true},
+		{name: "short trace id", traceparent: "00-" + testTraceID[1:] + "-" + testSpanID + "-01", wantErr: true},
+		{name: "zero trace id", traceparent: "00-" + strings.Repeat("0", 32) + "-" + testSpanID + "-01", wantErr: true},
+		{name: "zero span id", traceparent: "00-" + testTraceID + "-" + strings.Repeat("0", 16) + "-01", wantErr: true},
+		{name: "invalid flags", traceparent: "00-" + testTraceID + "-" + testSpanID + "-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, spanID, err := ParseTraceparent(tt.traceparent)
+			if tt.wantErr {
+				assert.Equal(t, ErrInvalidTraceparent, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testTraceID, traceID)
+			assert.Equal(t, testSpanID, spanID)
+		})
+	}
+}
+
+func TestFromContext_trace(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	console := new(bytes.Buffer)
+	jsonw := new(bytes.Buffer)
+	Configure(ConsoleWriter(console), JSONWriter(jsonw), Mode(mode.Production), AppName("app"))
+
+	ctx := WithContext(context.Background(), zap.String("request_id", "abc"))
+	ctx = WithTraceparent(ctx, testTraceparent)
+	FromContext(ctx).Info("traced")
+
+	assert.Contains(t, jsonw.String(), `"trace_id":"`+testTraceID+`","span_id":"`+testSpanID+`","@source_host":`)
+	assert.Contains(t, jsonw.String(), `"@fields":{"application":"app","request_id":"abc",`)
+	assert.Contains(t, console.String(), "message=traced trace="+testTraceID+"/"+testSpanID+" @source_host=")
+
+	// malformed traceparents are ignored
+	jsonw.Reset()
+	FromContext(WithTraceparent(context.Background(), "00-nope")).Info("untraced")
+	assert.NotContains(t, jsonw.String(), "trace_id")
+}
+
+func TestTraceFrom(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	type spanKey struct{}
+	jsonw := new(bytes.Buffer)
+	Configure(
+		ConsoleWriter(nil),
+		JSONWriter(jsonw),
+		TraceFrom(func(ctx context.Context) (string, string, bool) {
+			span, ok := ctx.Value(spanKey{}).(string)
+			return "trace-of-" + span, span, ok
+		}),
+	)
+
+	FromContext(context.WithValue(context.Background(), spanKey{}, "span")).Info("custom")
+	assert.Contains(t, jsonw.String(), `"trace_id":"trace-of-span","span_id":"span"`)
+
+	// the default traceparent extractor is replaced
+	jsonw.Reset()
+	FromContext(WithTraceparent(context.Background(), testTraceparent)).Info("untraced")
+	assert.NotContains(t, jsonw.String(), "trace_id")
+}
+
+func TestMiddleware_trace(t *testing.T) {
+	before()
+	defer func() { after(); Configure() }()
+	defer registry.Reset()
+
+	jsonw := new(bytes.Buffer)
+	Configure(ConsoleWriter(nil), JSONWriter(jsonw), Mode(mode.Production))
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("handling")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(TraceparentHeader, testTraceparent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(jsonw.String()), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Contains(t, line, `"trace_id":"`+testTraceID+`","span_id":"`+testSpanID+`"`)
+	}
+}
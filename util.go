@@ -18,16 +18,20 @@ func hostname() string {
 	return h
 }
 
+// pkgname returns the package of the caller of the func calling pkgname
 func pkgname() registry.Package {
 	pc, _, _, _ := runtime.Caller(2)
-	parts := strings.Split(runtime.FuncForPC(pc).Name(), ".")
-	pl := len(parts)
-	pkg := ""
+	return funcPackage(runtime.FuncForPC(pc).Name())
+}
 
-	if parts[pl-2][0] == '(' {
-		pkg = strings.Join(parts[0:pl-2], ".")
-	} else {
-		pkg = strings.Join(parts[0:pl-1], ".")
+// funcPackage returns the package of a fully qualified func name, such as
+// github.com/acme/svc.(*Server).handle.func1. The package ends at the first
+// dot after the last slash, as dots in the last element of an import path
+// are escaped in func names
+func funcPackage(name string) registry.Package {
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+		name = name[:slash+1+dot]
 	}
-	return registry.Package(pkg)
+	return registry.Package(strings.Replace(name, "%2e", ".", -1))
 }
@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/syllabix/logger/internal/registry"
+)
+
+func Test_funcPackage(t *testing.T) {
+	tests := []struct {
+		name string
+		want registry.Package
+	}{
+		{name: "main.main", want: "main"},
+		{name: "github.com/acme/svc.handle", want: "github.com/acme/svc"},
+		{name: "github.com/acme/svc.(*Server).handle", want: "github.com/acme/svc"},
+		{name: "github.com/acme/svc.(*Server).handle.func1", want: "github.com/acme/svc"},
+		{name: "github.com/acme/svc.init.0.func2.1", want: "github.com/acme/svc"},
+		{name: "gopkg.in/yaml%2ev3.Unmarshal", want: "gopkg.in/yaml.v3"},
+		{name: "github.com/acme/svc", want: "github.com/acme/svc"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, funcPackage(tt.name), tt.name)
+	}
+}